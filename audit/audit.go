@@ -0,0 +1,209 @@
+// Package audit implements a tamper-evident log of all poll operations.
+//
+// Each entry contains the hash of the previous entry and is signed with the
+// main key. An entry can not be changed, removed or inserted without breaking
+// the chain. Use Verify() to check a log.
+package audit
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/OpenSlides/vote-decrypt/decrypt"
+)
+
+// Signer signs the entries of the log. crypto.Crypto implements it.
+type Signer interface {
+	Sign(value []byte) []byte
+	PublicMainKey() []byte
+}
+
+// Sink stores the encoded entries of the log.
+//
+// Records have to be returned in the order they were appended.
+type Sink interface {
+	AppendAudit(record []byte) error
+	AuditRecords() ([][]byte, error)
+}
+
+// Entry is one entry of the audit log.
+type Entry struct {
+	Seq             uint64    `json:"seq"`
+	Time            time.Time `json:"time"`
+	Operation       string    `json:"operation"`
+	PollID          string    `json:"poll_id"`
+	Tenant          string    `json:"tenant,omitempty"`
+	Votes           int       `json:"votes,omitempty"`
+	InputDigest     []byte    `json:"input_digest,omitempty"`
+	PublicKey       []byte    `json:"public_key,omitempty"`
+	ResultSignature []byte    `json:"result_signature,omitempty"`
+
+	// PrevHash is the hash of the previous entry. It is empty for the first
+	// entry.
+	PrevHash []byte `json:"prev_hash,omitempty"`
+
+	// Hash is the sha256 hash of the entry without Hash and Signature.
+	Hash []byte `json:"hash"`
+
+	// Signature is the signature of Hash created with the main key.
+	Signature []byte `json:"signature"`
+}
+
+// hash calculates the hash of the entry.
+func (e Entry) hash() ([]byte, error) {
+	e.Hash = nil
+	e.Signature = nil
+
+	encoded, err := json.Marshal(e)
+	if err != nil {
+		return nil, fmt.Errorf("encoding entry: %w", err)
+	}
+
+	h := sha256.Sum256(encoded)
+	return h[:], nil
+}
+
+// Log writes entries to a sink. It implements decrypt.AuditLog.
+type Log struct {
+	mu sync.Mutex
+
+	sink   Sink
+	signer Signer
+
+	operations map[string]bool // if not nil, only this operations are logged.
+
+	seq      uint64
+	lastHash []byte
+}
+
+// Option for audit.New().
+type Option = func(*Log)
+
+// WithOperations only logs the given operations. All other events are
+// ignored. As default, all operations are logged.
+func WithOperations(operations ...string) Option {
+	return func(l *Log) {
+		l.operations = make(map[string]bool, len(operations))
+		for _, op := range operations {
+			l.operations[op] = true
+		}
+	}
+}
+
+// New initializes a Log. It verifies the existing entries of the sink and
+// continues its chain.
+func New(sink Sink, signer Signer, options ...Option) (*Log, error) {
+	records, err := sink.AuditRecords()
+	if err != nil {
+		return nil, fmt.Errorf("reading existing entries: %w", err)
+	}
+
+	last, err := Verify(records, signer.PublicMainKey())
+	if err != nil {
+		return nil, fmt.Errorf("verifying existing entries: %w", err)
+	}
+
+	l := Log{
+		sink:     sink,
+		signer:   signer,
+		seq:      last.Seq,
+		lastHash: last.Hash,
+	}
+
+	for _, o := range options {
+		o(&l)
+	}
+
+	return &l, nil
+}
+
+// Record appends an event to the log.
+func (l *Log) Record(event decrypt.AuditEvent) error {
+	if l.operations != nil && !l.operations[event.Operation] {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry := Entry{
+		Seq:             l.seq + 1,
+		Time:            event.Time.UTC(),
+		Operation:       event.Operation,
+		PollID:          event.PollID,
+		Tenant:          event.Tenant,
+		Votes:           event.Votes,
+		InputDigest:     event.InputDigest,
+		PublicKey:       event.PublicKey,
+		ResultSignature: event.ResultSignature,
+		PrevHash:        l.lastHash,
+	}
+
+	hash, err := entry.hash()
+	if err != nil {
+		return fmt.Errorf("hashing entry: %w", err)
+	}
+	entry.Hash = hash
+	entry.Signature = l.signer.Sign(hash)
+
+	record, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encoding entry: %w", err)
+	}
+
+	if err := l.sink.AppendAudit(record); err != nil {
+		return fmt.Errorf("appending entry: %w", err)
+	}
+
+	l.seq = entry.Seq
+	l.lastHash = entry.Hash
+	return nil
+}
+
+// Verify checks the chain of the records and the signature of each entry.
+//
+// It returns the last entry. If there are no records, the zero Entry is
+// returned.
+func Verify(records [][]byte, pubKey []byte) (Entry, error) {
+	if len(pubKey) != ed25519.PublicKeySize {
+		return Entry{}, fmt.Errorf("invalid public key")
+	}
+
+	var last Entry
+	for i, record := range records {
+		var entry Entry
+		if err := json.Unmarshal(record, &entry); err != nil {
+			return Entry{}, fmt.Errorf("decoding entry %d: %w", i+1, err)
+		}
+
+		if entry.Seq != last.Seq+1 {
+			return Entry{}, fmt.Errorf("entry %d has sequence number %d", i+1, entry.Seq)
+		}
+
+		if !bytes.Equal(entry.PrevHash, last.Hash) {
+			return Entry{}, fmt.Errorf("entry %d does not point to the previous entry", entry.Seq)
+		}
+
+		hash, err := entry.hash()
+		if err != nil {
+			return Entry{}, fmt.Errorf("hashing entry %d: %w", entry.Seq, err)
+		}
+
+		if !bytes.Equal(hash, entry.Hash) {
+			return Entry{}, fmt.Errorf("entry %d was modified", entry.Seq)
+		}
+
+		if !ed25519.Verify(pubKey, entry.Hash, entry.Signature) {
+			return Entry{}, fmt.Errorf("entry %d has an invalid signature", entry.Seq)
+		}
+
+		last = entry
+	}
+
+	return last, nil
+}
@@ -0,0 +1,146 @@
+package audit_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/OpenSlides/vote-decrypt/audit"
+	"github.com/OpenSlides/vote-decrypt/crypto"
+	"github.com/OpenSlides/vote-decrypt/decrypt"
+)
+
+type memorySink struct {
+	records [][]byte
+}
+
+func (s *memorySink) AppendAudit(record []byte) error {
+	s.records = append(s.records, bytes.Clone(record))
+	return nil
+}
+
+func (s *memorySink) AuditRecords() ([][]byte, error) {
+	return s.records, nil
+}
+
+func newSigner() crypto.Crypto {
+	return crypto.New(make([]byte, 32), rand.Reader, nil)
+}
+
+func writeEntries(t *testing.T, sink audit.Sink, signer audit.Signer) {
+	t.Helper()
+
+	log, err := audit.New(sink, signer)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	for _, event := range []decrypt.AuditEvent{
+		{Operation: decrypt.AuditStart, PollID: "test/1", Time: time.Now(), ResultSignature: []byte("sig")},
+		{Operation: decrypt.AuditStop, PollID: "test/1", Time: time.Now(), Votes: 3, InputDigest: decrypt.VotesDigest([][]byte{[]byte("vote")}), ResultSignature: []byte("sig")},
+		{Operation: decrypt.AuditClear, PollID: "test/1", Time: time.Now()},
+	} {
+		if err := log.Record(event); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	signer := newSigner()
+	sink := new(memorySink)
+	writeEntries(t, sink, signer)
+
+	last, err := audit.Verify(sink.records, signer.PublicMainKey())
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+
+	if last.Seq != 3 || last.Operation != decrypt.AuditClear {
+		t.Errorf("got last entry %d %s, expected 3 clear", last.Seq, last.Operation)
+	}
+}
+
+func TestVerifyTampered(t *testing.T) {
+	signer := newSigner()
+
+	for _, tt := range []struct {
+		name   string
+		tamper func([][]byte) [][]byte
+	}{
+		{
+			"modified",
+			func(records [][]byte) [][]byte {
+				records[1] = bytes.Replace(records[1], []byte(`"votes":3`), []byte(`"votes":4`), 1)
+				return records
+			},
+		},
+		{
+			"removed",
+			func(records [][]byte) [][]byte {
+				return append(records[:1], records[2:]...)
+			},
+		},
+		{
+			"reordered",
+			func(records [][]byte) [][]byte {
+				records[0], records[1] = records[1], records[0]
+				return records
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := new(memorySink)
+			writeEntries(t, sink, signer)
+
+			if _, err := audit.Verify(tt.tamper(sink.records), signer.PublicMainKey()); err == nil {
+				t.Errorf("Verify did not return an error")
+			}
+		})
+	}
+
+	t.Run("other key", func(t *testing.T) {
+		sink := new(memorySink)
+		writeEntries(t, sink, signer)
+
+		otherKey := bytes.Repeat([]byte{1}, 32)
+		if _, err := audit.Verify(sink.records, crypto.New(otherKey, rand.Reader, nil).PublicMainKey()); err == nil {
+			t.Errorf("Verify did not return an error")
+		}
+	})
+}
+
+func TestContinueChain(t *testing.T) {
+	signer := newSigner()
+	sink := audit.NewFileSink(filepath.Join(t.TempDir(), "audit.log"))
+
+	writeEntries(t, sink, signer)
+	writeEntries(t, sink, signer)
+
+	records, err := sink.AuditRecords()
+	if err != nil {
+		t.Fatalf("AuditRecords: %v", err)
+	}
+
+	last, err := audit.Verify(records, signer.PublicMainKey())
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+
+	if last.Seq != 6 {
+		t.Errorf("got %d entries, expected 6", last.Seq)
+	}
+}
+
+func TestNewTampered(t *testing.T) {
+	signer := newSigner()
+	sink := new(memorySink)
+	writeEntries(t, sink, signer)
+	sink.records = sink.records[1:]
+
+	if _, err := audit.New(sink, signer); err == nil {
+		t.Errorf("New did not return an error for a tampered log")
+	}
+}
@@ -0,0 +1,92 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileSink writes the entries to a file. Each line is one entry encoded as
+// json.
+type FileSink struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileSink initializes a FileSink.
+func NewFileSink(path string) *FileSink {
+	return &FileSink{path: path}
+}
+
+// AppendAudit appends a record to the file. The file is synced before the
+// method returns.
+func (s *FileSink) AppendAudit(record []byte) (err error) {
+	if bytes.ContainsAny(record, "\r\n") {
+		return fmt.Errorf("record contains a newline")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.path), os.ModePerm); err != nil {
+		return fmt.Errorf("creating directory: %w", err)
+	}
+
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("open audit file: %w", err)
+	}
+
+	defer func() {
+		if cErr := f.Close(); err == nil && cErr != nil {
+			err = fmt.Errorf("closing audit file: %w", cErr)
+		}
+	}()
+
+	if _, err := f.Write(append(record, '\n')); err != nil {
+		return fmt.Errorf("writing record: %w", err)
+	}
+
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("syncing audit file: %w", err)
+	}
+
+	return nil
+}
+
+// AuditRecords returns all records from the file. Returns nil, if the file
+// does not exist.
+func (s *FileSink) AuditRecords() ([][]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.Open(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("open audit file: %w", err)
+	}
+	defer f.Close()
+
+	var records [][]byte
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		records = append(records, bytes.Clone(line))
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading audit file: %w", err)
+	}
+
+	return records, nil
+}
@@ -0,0 +1,127 @@
+// Package auth implements the authentication of the callers of the service.
+//
+// Each caller belongs to a tenant. A tenant can only access polls with an id,
+// that starts with the name of the tenant followed by a slash. For example the
+// tenant `instance1` can access the poll `instance1/42`.
+//
+// The tenant `*` is an admin. It can access all polls and the admin service.
+package auth
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Admin is the name of the tenant, that can access everything.
+const Admin = "*"
+
+type contextKey int
+
+const tenantKey contextKey = iota
+
+// WithTenant returns a context that contains the authenticated tenant.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey, tenant)
+}
+
+// FromContext returns the authenticated tenant from the context.
+//
+// Returns false, if the caller was not authenticated.
+func FromContext(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantKey).(string)
+	return tenant, ok
+}
+
+// Allowed returns true, if the tenant can access the poll.
+func Allowed(tenant string, pollID string) bool {
+	if tenant == Admin {
+		return true
+	}
+	return tenant != "" && strings.HasPrefix(pollID, tenant+"/")
+}
+
+// ValidateTenant makes sure, that the name can be used as a tenant.
+func ValidateTenant(name string) error {
+	if name == Admin {
+		return nil
+	}
+
+	if name == "" {
+		return fmt.Errorf("tenant name is empty")
+	}
+
+	for _, c := range name {
+		if !((c >= 'a' && c <= 'z') ||
+			(c >= 'A' && c <= 'Z') ||
+			(c >= '0' && c <= '9') ||
+			c == '.') {
+			return fmt.Errorf("tenant name contains invalid character %c", c)
+		}
+	}
+	return nil
+}
+
+// Tokens maps api tokens to tenants.
+//
+// Only the hash of the tokens is kept in memory.
+type Tokens struct {
+	tenants map[[32]byte]string
+}
+
+// ParseTokens reads the tokens from r.
+//
+// Each line has the form `TENANT TOKEN`. Empty lines and lines starting with
+// `#` are ignored. A tenant can have more then one token.
+func ParseTokens(r io.Reader) (Tokens, error) {
+	tokens := Tokens{tenants: make(map[[32]byte]string)}
+
+	scanner := bufio.NewScanner(r)
+	var lineNr int
+	for scanner.Scan() {
+		lineNr++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return Tokens{}, fmt.Errorf("line %d: expected `TENANT TOKEN`", lineNr)
+		}
+
+		tenant, token := fields[0], fields[1]
+		if err := ValidateTenant(tenant); err != nil {
+			return Tokens{}, fmt.Errorf("line %d: %w", lineNr, err)
+		}
+
+		hash := sha256.Sum256([]byte(token))
+		if _, exists := tokens.tenants[hash]; exists {
+			return Tokens{}, fmt.Errorf("line %d: token is used more then once", lineNr)
+		}
+		tokens.tenants[hash] = tenant
+	}
+
+	if err := scanner.Err(); err != nil {
+		return Tokens{}, fmt.Errorf("reading tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// Tenant returns the tenant for a token.
+//
+// The token is hashed before the lookup, so the time of the lookup does not
+// depend on the token.
+func (t Tokens) Tenant(token string) (string, bool) {
+	tenant, ok := t.tenants[sha256.Sum256([]byte(token))]
+	return tenant, ok
+}
+
+// Empty returns true, if no token is configured.
+func (t Tokens) Empty() bool {
+	return len(t.tenants) == 0
+}
@@ -0,0 +1,86 @@
+package auth_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/OpenSlides/vote-decrypt/auth"
+)
+
+func TestParseTokens(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		tokens, err := auth.ParseTokens(strings.NewReader(`
+			# comment
+			instance1 token1
+			instance1 token2
+
+			* admintoken
+		`))
+		if err != nil {
+			t.Fatalf("ParseTokens: %v", err)
+		}
+
+		for token, expect := range map[string]string{
+			"token1":     "instance1",
+			"token2":     "instance1",
+			"admintoken": auth.Admin,
+		} {
+			got, ok := tokens.Tenant(token)
+			if !ok || got != expect {
+				t.Errorf("Tenant(%s) returned %s, expected %s", token, got, expect)
+			}
+		}
+
+		if _, ok := tokens.Tenant("unknown"); ok {
+			t.Errorf("Tenant(unknown) returned a tenant")
+		}
+	})
+
+	for _, tt := range []struct {
+		name    string
+		content string
+	}{
+		{"missing token", "instance1"},
+		{"too many fields", "instance1 token foo"},
+		{"invalid tenant", "instance/1 token"},
+		{"duplicate token", "instance1 token\ninstance2 token"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := auth.ParseTokens(strings.NewReader(tt.content)); err == nil {
+				t.Errorf("ParseTokens did not return an error")
+			}
+		})
+	}
+}
+
+func TestAllowed(t *testing.T) {
+	for _, tt := range []struct {
+		tenant string
+		pollID string
+		expect bool
+	}{
+		{"instance1", "instance1/5", true},
+		{"instance1", "instance1/sub/5", true},
+		{"instance1", "instance2/5", false},
+		{"instance1", "instance10/5", false},
+		{"instance1", "5", false},
+		{"", "5", false},
+		{auth.Admin, "instance2/5", true},
+	} {
+		if got := auth.Allowed(tt.tenant, tt.pollID); got != tt.expect {
+			t.Errorf("Allowed(%q, %q) returned %t, expected %t", tt.tenant, tt.pollID, got, tt.expect)
+		}
+	}
+}
+
+func TestContext(t *testing.T) {
+	if _, ok := auth.FromContext(context.Background()); ok {
+		t.Errorf("empty context has a tenant")
+	}
+
+	ctx := auth.WithTenant(context.Background(), "instance1")
+	if tenant, ok := auth.FromContext(ctx); !ok || tenant != "instance1" {
+		t.Errorf("FromContext returned %s, expected instance1", tenant)
+	}
+}
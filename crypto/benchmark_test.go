@@ -7,7 +7,7 @@ import (
 	"github.com/OpenSlides/vote-decrypt/crypto"
 )
 
-func benchmarkDecrypt(b *testing.B, voteCount int, voteByteSize int) {
+func benchmarkDecrypt(b *testing.B, voteCount int, voteByteSize int, prepared bool) {
 	curve := ecdh.X25519()
 	cr := crypto.New(mockMainKey(), randomMock{}, curve)
 
@@ -29,26 +29,50 @@ func benchmarkDecrypt(b *testing.B, voteCount int, voteByteSize int) {
 		votes[i] = encrypted
 	}
 
+	decrypt := func(vote []byte) ([]byte, error) {
+		return cr.Decrypt(privKey.Bytes(), vote)
+	}
+
+	if prepared {
+		decrypter, err := cr.NewDecrypter(privKey.Bytes())
+		if err != nil {
+			b.Fatalf("preparing key: %v", err)
+		}
+		decrypt = decrypter.Decrypt
+	}
+
 	b.ResetTimer()
 
 	for n := 0; n < b.N; n++ {
 		for i := 0; i < voteCount; i++ {
-			if _, err := cr.Decrypt(privKey.Bytes(), votes[i]); err != nil {
+			if _, err := decrypt(votes[i]); err != nil {
 				b.Errorf("decrypting: %v", err)
 			}
 		}
 	}
 }
 
-func BenchmarkDecrypt_1Votes_Byte100(b *testing.B)    { benchmarkDecrypt(b, 1, 100) }
-func BenchmarkDecrypt_10Votes_Byte100(b *testing.B)   { benchmarkDecrypt(b, 10, 100) }
-func BenchmarkDecrypt_100Votes_Byte100(b *testing.B)  { benchmarkDecrypt(b, 100, 100) }
-func BenchmarkDecrypt_1000Votes_Byte100(b *testing.B) { benchmarkDecrypt(b, 1_000, 100) }
+// benchmarkDecryptBig is like benchmarkDecrypt but is skipped with -short,
+// since encrypting the votes takes some time.
+func benchmarkDecryptBig(b *testing.B, voteCount int, voteByteSize int) {
+	if testing.Short() {
+		b.Skip("skipping big benchmark in short mode")
+	}
+	benchmarkDecrypt(b, voteCount, voteByteSize, false)
+}
+
+func BenchmarkDecrypt_1Votes_Byte100(b *testing.B)    { benchmarkDecrypt(b, 1, 100, false) }
+func BenchmarkDecrypt_10Votes_Byte100(b *testing.B)   { benchmarkDecrypt(b, 10, 100, false) }
+func BenchmarkDecrypt_100Votes_Byte100(b *testing.B)  { benchmarkDecrypt(b, 100, 100, false) }
+func BenchmarkDecrypt_1000Votes_Byte100(b *testing.B) { benchmarkDecrypt(b, 1_000, 100, false) }
+
+func BenchmarkDecrypt_10000Votes_Byte100(b *testing.B)  { benchmarkDecryptBig(b, 10_000, 100) }
+func BenchmarkDecrypt_100000Votes_Byte100(b *testing.B) { benchmarkDecryptBig(b, 100_000, 100) }
 
-// func BenchmarkDecrypt_10000Votes_Byte100(b *testing.B)  { benchmarkDecrypt(b, 10_000, 100) }
-// func BenchmarkDecrypt_100000Votes_Byte100(b *testing.B) { benchmarkDecrypt(b, 100_000, 100) }
+func BenchmarkDecrypt_1Votes_Byte1000(b *testing.B)    { benchmarkDecrypt(b, 1, 1_000, false) }
+func BenchmarkDecrypt_10Votes_Byte1000(b *testing.B)   { benchmarkDecrypt(b, 10, 1_000, false) }
+func BenchmarkDecrypt_100Votes_Byte1000(b *testing.B)  { benchmarkDecrypt(b, 100, 1_000, false) }
+func BenchmarkDecrypt_1000Votes_Byte1000(b *testing.B) { benchmarkDecrypt(b, 1_000, 1_000, false) }
 
-func BenchmarkDecrypt_1Votes_Byte1000(b *testing.B)    { benchmarkDecrypt(b, 1, 1_000) }
-func BenchmarkDecrypt_10Votes_Byte1000(b *testing.B)   { benchmarkDecrypt(b, 10, 1_000) }
-func BenchmarkDecrypt_100Votes_Byte1000(b *testing.B)  { benchmarkDecrypt(b, 100, 1_000) }
-func BenchmarkDecrypt_1000Votes_Byte1000(b *testing.B) { benchmarkDecrypt(b, 1_000, 1_000) }
+func BenchmarkDecrypter_1000Votes_Byte100(b *testing.B)  { benchmarkDecrypt(b, 1_000, 100, true) }
+func BenchmarkDecrypter_1000Votes_Byte1000(b *testing.B) { benchmarkDecrypt(b, 1_000, 1_000, true) }
@@ -19,6 +19,8 @@ import (
 	"fmt"
 	"io"
 
+	"github.com/OpenSlides/vote-decrypt/decrypt"
+	"github.com/OpenSlides/vote-decrypt/errorcode"
 	"golang.org/x/crypto/hkdf"
 )
 
@@ -26,6 +28,15 @@ const (
 	nonceSize = 12
 )
 
+// Errors for invalid ciphertexts. They are created once, so an invalid vote
+// does not need the time to format an error message.
+var (
+	errInvalidCipher    = fmt.Errorf("invalid cipher: %w", errorcode.Invalid)
+	errInvalidPublicKey = fmt.Errorf("invalid public key in ciphertext: %w", errorcode.Invalid)
+	errSharedSecret     = fmt.Errorf("creating shared secret: %w", errorcode.Invalid)
+	errDecrypt          = fmt.Errorf("decrypting ciphertext: %w", errorcode.Invalid)
+)
+
 // Crypto implements all cryptographic functions needed for the decrypt service.
 type Crypto struct {
 	mainKey ed25519.PrivateKey
@@ -84,47 +95,68 @@ func (c Crypto) PublicPollKey(privateKey []byte) (pubKey []byte, pubKeySig []byt
 
 // Decrypt returned the plaintext from value using the key.
 //
-// ciphertext contains three values. The first 32 bytes is the public empheral
-// key from the client. The next 12 byte is the used nonce for aes-gcm. All
-// later bytes are the encrypted vote.
-//
-// This function uses x25519 as described in rfc 7748. It uses hkdf with sha256
-// for the key derivation.
+// It parses the key for each call. Use NewDecrypter to decrypt many values
+// with the same key.
 func (c Crypto) Decrypt(privateKey []byte, ciphertext []byte) ([]byte, error) {
-	if len(ciphertext) < 1 {
-		return nil, fmt.Errorf("invalid cipher")
-	}
-
-	pubKeySize := ciphertext[0]
-
-	if len(ciphertext) < int(pubKeySize)+1+nonceSize {
-		return nil, fmt.Errorf("invalid cipher")
-	}
-
-	ephemeralPublicKey, err := c.curve.NewPublicKey(ciphertext[1 : 1+pubKeySize])
+	decrypter, err := c.NewDecrypter(privateKey)
 	if err != nil {
-		return nil, fmt.Errorf("invalid publick key in ciphertext: %w", err)
+		return nil, err
 	}
 
-	nonce := ciphertext[1+pubKeySize : 1+pubKeySize+nonceSize]
+	return decrypter.Decrypt(ciphertext)
+}
 
+// NewDecrypter parses the private poll key and returns an object to decrypt
+// votes with this key.
+func (c Crypto) NewDecrypter(privateKey []byte) (decrypt.Decrypter, error) {
 	privKey, err := c.curve.NewPrivateKey(privateKey)
 	if err != nil {
 		return nil, fmt.Errorf("initializing private key: %w", err)
 	}
 
-	sharedSecred, err := privKey.ECDH(ephemeralPublicKey)
-	if err != nil {
-		return nil, fmt.Errorf("creating shared secred: %w", err)
+	return Decrypter{curve: c.curve, privKey: privKey}, nil
+}
+
+// Decrypter decrypts votes with a parsed private poll key.
+//
+// It can be used concurrently.
+type Decrypter struct {
+	curve   ecdh.Curve
+	privKey *ecdh.PrivateKey
+}
+
+// Decrypt returned the plaintext from the ciphertext.
+//
+// ciphertext contains three values. The first 32 bytes is the public empheral
+// key from the client. The next 12 byte is the used nonce for aes-gcm. All
+// later bytes are the encrypted vote.
+//
+// This function uses x25519 as described in rfc 7748. It uses hkdf with sha256
+// for the key derivation.
+//
+// Invalid ciphertexts are decrypted with dummy values, so they take the same
+// time as valid ones. Only aes-gcm skips the decryption, if the
+// authentication fails.
+func (d Decrypter) Decrypt(ciphertext []byte) ([]byte, error) {
+	ephemeralPublicKey, nonce, encrypted, invalidErr := d.parse(ciphertext)
+	if invalidErr != nil {
+		ephemeralPublicKey = d.privKey.PublicKey()
+		nonce = make([]byte, nonceSize)
+		encrypted = ciphertext[min(len(ciphertext), 1+len(ephemeralPublicKey.Bytes())+nonceSize):]
 	}
 
-	hkdf := hkdf.New(sha256.New, sharedSecred, nil, nil)
-	key := make([]byte, 32)
-	if _, err := io.ReadFull(hkdf, key); err != nil {
+	sharedSecred, err := d.privKey.ECDH(ephemeralPublicKey)
+	if err != nil && invalidErr == nil {
+		invalidErr = errSharedSecret
+		sharedSecred = make([]byte, 32)
+	}
+
+	var key [32]byte
+	if _, err := io.ReadFull(hkdf.New(sha256.New, sharedSecred, nil, nil), key[:]); err != nil {
 		return nil, fmt.Errorf("generate key with hkdf: %w", err)
 	}
 
-	block, err := aes.NewCipher(key)
+	block, err := aes.NewCipher(key[:])
 	if err != nil {
 		return nil, fmt.Errorf("creating aes chipher: %w", err)
 	}
@@ -134,14 +166,39 @@ func (c Crypto) Decrypt(privateKey []byte, ciphertext []byte) ([]byte, error) {
 		return nil, fmt.Errorf("create gcm mode: %w", err)
 	}
 
-	plaintext, err := mode.Open(nil, nonce, ciphertext[1+pubKeySize+nonceSize:], nil)
+	plaintext, err := mode.Open(nil, nonce, encrypted, nil)
+	if invalidErr != nil {
+		return nil, invalidErr
+	}
+
 	if err != nil {
-		return nil, fmt.Errorf("decrypting ciphertext: %w", err)
+		return nil, errDecrypt
 	}
 
 	return plaintext, nil
 }
 
+// parse splits the ciphertext into its parts.
+func (d Decrypter) parse(ciphertext []byte) (*ecdh.PublicKey, []byte, []byte, error) {
+	if len(ciphertext) < 1 {
+		return nil, nil, nil, errInvalidCipher
+	}
+
+	pubKeySize := int(ciphertext[0])
+
+	if len(ciphertext) < pubKeySize+1+nonceSize {
+		return nil, nil, nil, errInvalidCipher
+	}
+
+	ephemeralPublicKey, err := d.curve.NewPublicKey(ciphertext[1 : 1+pubKeySize])
+	if err != nil {
+		return nil, nil, nil, errInvalidPublicKey
+	}
+
+	nonce := ciphertext[1+pubKeySize : 1+pubKeySize+nonceSize]
+	return ephemeralPublicKey, nonce, ciphertext[1+pubKeySize+nonceSize:], nil
+}
+
 // Sign returns the signature for the given data.
 func (c Crypto) Sign(value []byte) []byte {
 	return ed25519.Sign(c.mainKey, value)
@@ -3,9 +3,11 @@ package crypto_test
 import (
 	"crypto/ecdh"
 	"crypto/ed25519"
+	"errors"
 	"testing"
 
 	"github.com/OpenSlides/vote-decrypt/crypto"
+	"github.com/OpenSlides/vote-decrypt/errorcode"
 )
 
 func TestCreatePollKey(t *testing.T) {
@@ -62,6 +64,49 @@ func TestDecrypt(t *testing.T) {
 	}
 }
 
+func TestNewDecrypterInvalidKey(t *testing.T) {
+	c := crypto.New(mockMainKey(), randomMock{}, nil)
+
+	if _, err := c.NewDecrypter([]byte("short")); err == nil {
+		t.Errorf("NewDecrypter with invalid key did not fail")
+	}
+}
+
+func TestDecryptInvalid(t *testing.T) {
+	curve := ecdh.X25519()
+
+	c := crypto.New(mockMainKey(), randomMock{}, curve)
+
+	privKey, err := curve.GenerateKey(randomMock{})
+	if err != nil {
+		t.Fatalf("creating private key: %v", err)
+	}
+
+	encrypted, err := crypto.Encrypt(randomMock{}, curve, privKey.PublicKey().Bytes(), []byte("my vote"))
+	if err != nil {
+		t.Fatalf("encrypting plaintext: %v", err)
+	}
+
+	manipulated := append([]byte{}, encrypted...)
+	manipulated[len(manipulated)-1] ^= 1
+
+	for _, tt := range []struct {
+		name       string
+		ciphertext []byte
+	}{
+		{"empty", nil},
+		{"too short", encrypted[:10]},
+		{"manipulated", manipulated},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := c.Decrypt(privKey.Bytes(), tt.ciphertext)
+			if !errors.Is(err, errorcode.Invalid) {
+				t.Errorf("decrypt returned `%v`, expected `%v`", err, errorcode.Invalid)
+			}
+		})
+	}
+}
+
 func TestSign(t *testing.T) {
 	c := crypto.New(mockMainKey(), randomMock{}, nil)
 
@@ -0,0 +1,62 @@
+package crypto_test
+
+import (
+	"crypto/ecdh"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/OpenSlides/vote-decrypt/crypto"
+	"github.com/OpenSlides/vote-decrypt/internal/dudect"
+)
+
+// TestDecryptTiming measures, that invalid votes take the same time as valid
+// ones. It takes some time and can fail on a busy machine. So it only runs,
+// when the environment variable VOTE_DECRYPT_TIMING_TEST is set.
+func TestDecryptTiming(t *testing.T) {
+	if os.Getenv("VOTE_DECRYPT_TIMING_TEST") == "" {
+		t.Skip("set VOTE_DECRYPT_TIMING_TEST to run the timing test")
+	}
+
+	curve := ecdh.X25519()
+	c := crypto.New(mockMainKey(), randomMock{}, curve)
+
+	privKey, err := curve.GenerateKey(randomMock{})
+	if err != nil {
+		t.Fatalf("creating private key: %v", err)
+	}
+
+	decrypter, err := c.NewDecrypter(privKey.Bytes())
+	if err != nil {
+		t.Fatalf("preparing key: %v", err)
+	}
+
+	valid, err := crypto.Encrypt(randomMock{}, curve, privKey.PublicKey().Bytes(), make([]byte, 100))
+	if err != nil {
+		t.Fatalf("encrypting vote: %v", err)
+	}
+
+	for _, tt := range []struct {
+		name    string
+		invalid []byte
+	}{
+		{"empty", nil},
+		{"too short", valid[:20]},
+		{"invalid public key size", append([]byte{31}, valid[1:]...)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got := dudect.Measure(5_000, func(class int) func() {
+				vote := valid
+				if class == 1 {
+					vote = tt.invalid
+				}
+				return func() { decrypter.Decrypt(vote) }
+			})
+
+			if math.Abs(got) > dudect.Threshold {
+				t.Errorf("got t=%.2f, the time of an invalid vote differs from a valid one", got)
+			}
+			t.Logf("t=%.2f", got)
+		})
+	}
+}
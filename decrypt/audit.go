@@ -0,0 +1,117 @@
+package decrypt
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/OpenSlides/vote-decrypt/errorcode"
+)
+
+// Operations, that are written to the audit log.
+const (
+	AuditStart  = "start"
+	AuditStop   = "stop"
+	AuditClear  = "clear"
+	AuditExpire = "expire"
+)
+
+// AuditLog records the operations on polls. See the package audit for an
+// implementation.
+type AuditLog interface {
+	// Record writes the event to the log. If it returns an error, the
+	// operation fails.
+	Record(event AuditEvent) error
+}
+
+// AuditEvent is an operation on a poll, that is written to the audit log.
+//
+// It never contains votes or keys.
+type AuditEvent struct {
+	Operation string
+	PollID    string
+	Tenant    string
+	Time      time.Time
+
+	// Votes is the number of votes of a stop operation.
+	Votes int
+
+	// InputDigest is the digest of the votes of a stop operation. See
+	// VotesDigest().
+	InputDigest []byte
+
+	// PublicKey is the public poll key of a start operation.
+	PublicKey []byte
+
+	// ResultSignature is the signature of the public poll key for a start
+	// operation and the signature of the decrypted content for a stop
+	// operation.
+	ResultSignature []byte
+}
+
+// VotesDigest returns the sha256 digest of a list of encrypted votes.
+//
+// Each vote is prefixed with its length as big endian uint64.
+func VotesDigest(votes [][]byte) []byte {
+	h := sha256.New()
+	var length [8]byte
+	for _, vote := range votes {
+		binary.BigEndian.PutUint64(length[:], uint64(len(vote)))
+		h.Write(length[:])
+		h.Write(vote)
+	}
+	return h.Sum(nil)
+}
+
+// audit writes an event to all configured audit logs.
+func (d *Decrypt) audit(ctx context.Context, event AuditEvent) error {
+	event.Tenant = TenantFromContext(ctx)
+	event.Time = time.Now()
+
+	for _, log := range d.auditLogs {
+		if err := log.Record(event); err != nil {
+			return fmt.Errorf("writing audit log: %w", err)
+		}
+	}
+	return nil
+}
+
+// transition writes the event to the audit log and then changes the state of
+// the poll with setState.
+//
+// The event is written first, so it does not get lost, if the audit log
+// fails. The state is reloaded under a lock, so concurrent calls write the
+// event only once. If the poll already has the state, nothing is done.
+//
+// If setState fails after the event was written, the event is written again
+// on the next call. So the audit log can contain an operation twice, but it
+// never misses one.
+func (d *Decrypt) transition(ctx context.Context, state PollState, event AuditEvent, setState func() error) error {
+	d.transitionMu.Lock()
+	defer d.transitionMu.Unlock()
+
+	poll, err := d.store.LoadPoll(event.PollID)
+	if err != nil {
+		return fmt.Errorf("loading poll: %w", err)
+	}
+
+	switch {
+	case poll.State == state:
+		return nil
+	case state == StateStarted && !poll.State.CanStart():
+		return fmt.Errorf("poll is %s: %w", poll.State, errorcode.WrongState)
+	case state == StateStopped && (poll.State == StateCleared || poll.State == StateExpired):
+		return fmt.Errorf("poll is %s: %w", poll.State, errorcode.WrongState)
+	}
+
+	if err := d.audit(ctx, event); err != nil {
+		return err
+	}
+
+	if err := setState(); err != nil {
+		return fmt.Errorf("setting poll state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+package decrypt_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/OpenSlides/vote-decrypt/decrypt"
+)
+
+func TestAuditLog(t *testing.T) {
+	ctx := context.Background()
+	auditLog := new(auditMock)
+	d := decrypt.New(
+		cryptoMock{},
+		NewStoreMock(),
+		decrypt.WithRandomSource(randomMock{}),
+		decrypt.WithAuditLog(auditLog),
+	)
+
+	votes := func() [][]byte {
+		return [][]byte{[]byte(`enc:"Y"`), []byte(`enc:"N"`)}
+	}
+
+	// Start and Stop are called twice, but only the first call changes the
+	// state.
+	for i := 0; i < 2; i++ {
+		if _, _, err := d.Start(ctx, "test/1"); err != nil {
+			t.Fatalf("start: %v", err)
+		}
+	}
+
+	var signature []byte
+	for i := 0; i < 2; i++ {
+		var err error
+		_, signature, err = d.Stop(ctx, "test/1", votes())
+		if err != nil {
+			t.Fatalf("stop: %v", err)
+		}
+	}
+
+	if err := d.Clear(ctx, "test/1"); err != nil {
+		t.Fatalf("clear: %v", err)
+	}
+
+	var operations []string
+	for _, event := range auditLog.events {
+		operations = append(operations, event.Operation)
+		if event.PollID != "test/1" {
+			t.Errorf("got poll id %s, expected test/1", event.PollID)
+		}
+	}
+
+	expect := []string{decrypt.AuditStart, decrypt.AuditStop, decrypt.AuditClear}
+	if len(operations) != len(expect) {
+		t.Fatalf("got operations %v, expected %v", operations, expect)
+	}
+
+	stop := auditLog.events[1]
+	if stop.Votes != 2 {
+		t.Errorf("stop event has %d votes, expected 2", stop.Votes)
+	}
+
+	if !bytes.Equal(stop.InputDigest, decrypt.VotesDigest(votes())) {
+		t.Errorf("stop event has the wrong input digest")
+	}
+
+	if !bytes.Equal(stop.ResultSignature, signature) {
+		t.Errorf("stop event has signature %s, expected %s", stop.ResultSignature, signature)
+	}
+}
+
+// failOnceAudit is an audit log, that fails on the first call of each
+// operation.
+type failOnceAudit struct {
+	auditMock
+	failed map[string]bool
+}
+
+func (a *failOnceAudit) Record(event decrypt.AuditEvent) error {
+	a.mu.Lock()
+	failed := a.failed[event.Operation]
+	a.failed[event.Operation] = true
+	a.mu.Unlock()
+
+	if !failed {
+		return errors.New("audit log is not available")
+	}
+	return a.auditMock.Record(event)
+}
+
+func TestAuditLogFails(t *testing.T) {
+	ctx := context.Background()
+	auditLog := &failOnceAudit{failed: make(map[string]bool)}
+	store := NewStoreMock()
+	d := decrypt.New(
+		cryptoMock{},
+		store,
+		decrypt.WithRandomSource(randomMock{}),
+		decrypt.WithAuditLog(auditLog),
+	)
+
+	votes := [][]byte{[]byte(`enc:"Y"`)}
+
+	for _, tt := range []struct {
+		operation string
+		call      func() error
+		state     decrypt.PollState
+	}{
+		{
+			decrypt.AuditStart,
+			func() error { _, _, err := d.Start(ctx, "test/1"); return err },
+			decrypt.StateStarted,
+		},
+		{
+			decrypt.AuditStop,
+			func() error { _, _, err := d.Stop(ctx, "test/1", votes); return err },
+			decrypt.StateStopped,
+		},
+		{
+			decrypt.AuditClear,
+			func() error { return d.Clear(ctx, "test/1") },
+			decrypt.StateCleared,
+		},
+	} {
+		if err := tt.call(); err == nil {
+			t.Fatalf("%s with failing audit log did not return an error", tt.operation)
+		}
+
+		poll, err := store.LoadPoll("test/1")
+		if err != nil {
+			t.Fatalf("LoadPoll: %v", err)
+		}
+
+		if poll.State == tt.state {
+			t.Errorf("%s changed the state without an audit entry", tt.operation)
+		}
+
+		if _, err := store.LoadKey("test/1"); err != nil {
+			t.Errorf("%s without an audit entry removed the key: %v", tt.operation, err)
+		}
+
+		if err := tt.call(); err != nil {
+			t.Fatalf("%s after audit log recovered: %v", tt.operation, err)
+		}
+	}
+
+	var operations []string
+	for _, event := range auditLog.events {
+		operations = append(operations, event.Operation)
+	}
+
+	expect := []string{decrypt.AuditStart, decrypt.AuditStop, decrypt.AuditClear}
+	if !slices.Equal(operations, expect) {
+		t.Errorf("got operations %v, expected %v", operations, expect)
+	}
+}
+
+func TestAuditLogFailsStopResultSaver(t *testing.T) {
+	ctx := context.Background()
+	store := &stopResultStore{StoreMock: NewStoreMock()}
+	d := decrypt.New(
+		cryptoMock{},
+		store,
+		decrypt.WithRandomSource(randomMock{}),
+		decrypt.WithAuditLog(&failOnceAudit{failed: map[string]bool{decrypt.AuditStart: true}}),
+	)
+
+	if _, _, err := d.Start(ctx, "test/1"); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	if _, _, err := d.Stop(ctx, "test/1", [][]byte{[]byte(`enc:"Y"`)}); err == nil {
+		t.Fatalf("stop with failing audit log did not return an error")
+	}
+
+	// The signature of the first call must not be saved. Otherwise, a stop
+	// with other votes would fail.
+	if _, _, err := d.Stop(ctx, "test/1", [][]byte{[]byte(`enc:"N"`)}); err != nil {
+		t.Errorf("stop after audit log recovered: %v", err)
+	}
+}
+
+func TestAuditLogConcurrent(t *testing.T) {
+	ctx := context.Background()
+	auditLog := new(auditMock)
+	d := decrypt.New(
+		cryptoMock{},
+		NewStoreMock(),
+		decrypt.WithRandomSource(randomMock{}),
+		decrypt.WithAuditLog(auditLog),
+	)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, err := d.Start(ctx, "test/1"); err != nil {
+				t.Errorf("start: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(auditLog.events) != 1 {
+		t.Errorf("got %d audit events for concurrent starts, expected one", len(auditLog.events))
+	}
+}
+
+func TestVotesDigest(t *testing.T) {
+	d1 := decrypt.VotesDigest([][]byte{[]byte("ab"), []byte("c")})
+	d2 := decrypt.VotesDigest([][]byte{[]byte("a"), []byte("bc")})
+
+	if bytes.Equal(d1, d2) {
+		t.Errorf("different vote lists have the same digest")
+	}
+}
@@ -0,0 +1,146 @@
+package decrypt_test
+
+import (
+	"context"
+	"crypto/ecdh"
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/OpenSlides/vote-decrypt/crypto"
+	"github.com/OpenSlides/vote-decrypt/decrypt"
+)
+
+// benchmarkStop measures Stop with voteCount votes and fails, if it allocates
+// more than allocsPerVote for each vote.
+//
+// All polls use the same poll key, since randomMock is used to create the
+// keys.
+func benchmarkStop(b *testing.B, cr decrypt.Crypto, votes [][]byte, allocsPerVote float64) {
+	d := decrypt.New(cr, NewStoreMock())
+	ctx := context.Background()
+
+	var mallocs uint64
+	var before, after runtime.MemStats
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		b.StopTimer()
+		pollID := fmt.Sprintf("bench/%d", n)
+		if _, _, err := d.Start(ctx, pollID); err != nil {
+			b.Fatalf("start: %v", err)
+		}
+		runtime.ReadMemStats(&before)
+		b.StartTimer()
+
+		if _, _, err := d.Stop(ctx, pollID, votes); err != nil {
+			b.Fatalf("stop: %v", err)
+		}
+
+		b.StopTimer()
+		runtime.ReadMemStats(&after)
+		mallocs += after.Mallocs - before.Mallocs
+		b.StartTimer()
+	}
+
+	got := float64(mallocs) / float64(b.N) / float64(len(votes))
+	b.ReportMetric(got, "allocs/vote")
+	if got > allocsPerVote {
+		b.Errorf("stop used %.2f allocations per vote, budget is %.2f", got, allocsPerVote)
+	}
+}
+
+// encryptedVotes returns voteCount votes encrypted with the poll key, that is
+// created from randomMock.
+func encryptedVotes(b testing.TB, voteCount int) [][]byte {
+	curve := ecdh.X25519()
+	cr := crypto.New(make([]byte, 32), randomMock{}, curve)
+
+	pollKey, err := cr.CreatePollKey()
+	if err != nil {
+		b.Fatalf("creating poll key: %v", err)
+	}
+
+	pubKey, _, err := cr.PublicPollKey(pollKey)
+	if err != nil {
+		b.Fatalf("creating public poll key: %v", err)
+	}
+
+	votes := make([][]byte, voteCount)
+	for i := range votes {
+		votes[i], err = crypto.Encrypt(randomMock{}, curve, pubKey, []byte(fmt.Sprintf(`"%d"`, i)))
+		if err != nil {
+			b.Fatalf("encrypting vote: %v", err)
+		}
+	}
+	return votes
+}
+
+// Allocation budgets per vote.
+const (
+	// allocsCrypto is the budget for the decryption with x25519, hkdf and
+	// aes-gcm.
+	allocsCrypto = 25
+
+	// allocsPipeline is the budget for the pipeline without the cryptographic
+	// functions. The mock decrypter does not allocate.
+	allocsPipeline = 0.25
+)
+
+func benchmarkStopCrypto(b *testing.B, voteCount int) {
+	cr := crypto.New(make([]byte, 32), randomMock{}, nil)
+	benchmarkStop(b, cr, encryptedVotes(b, voteCount), allocsCrypto)
+}
+
+func BenchmarkStop_1000Votes(b *testing.B)   { benchmarkStopCrypto(b, 1_000) }
+func BenchmarkStop_10000Votes(b *testing.B)  { benchmarkStopCrypto(b, 10_000) }
+func BenchmarkStop_100000Votes(b *testing.B) { benchmarkStopCrypto(b, 100_000) }
+
+func benchmarkStopPipeline(b *testing.B, voteCount int) {
+	benchmarkStop(b, cryptoMock{}, manyVotes(voteCount), allocsPipeline)
+}
+
+func BenchmarkStopPipeline_1000Votes(b *testing.B)   { benchmarkStopPipeline(b, 1_000) }
+func BenchmarkStopPipeline_10000Votes(b *testing.B)  { benchmarkStopPipeline(b, 10_000) }
+func BenchmarkStopPipeline_100000Votes(b *testing.B) { benchmarkStopPipeline(b, 100_000) }
+
+// TestStopAllocs checks the allocation budgets without -bench.
+func TestStopAllocs(t *testing.T) {
+	for _, tt := range []struct {
+		name          string
+		cr            decrypt.Crypto
+		votes         [][]byte
+		allocsPerVote float64
+	}{
+		{"crypto", crypto.New(make([]byte, 32), randomMock{}, nil), encryptedVotes(t, 1_000), allocsCrypto},
+		{"pipeline", cryptoMock{}, manyVotes(10_000), allocsPipeline},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			const runs = 5
+			d := decrypt.New(tt.cr, NewStoreMock())
+			ctx := context.Background()
+
+			// Each run stops another poll. AllocsPerRun calls the function
+			// one more time for warm up.
+			pollIDs := make([]string, runs+1)
+			for i := range pollIDs {
+				pollIDs[i] = fmt.Sprintf("test/%d", i)
+				if _, _, err := d.Start(ctx, pollIDs[i]); err != nil {
+					t.Fatalf("start: %v", err)
+				}
+			}
+
+			var run int
+			allocs := testing.AllocsPerRun(runs, func() {
+				if _, _, err := d.Stop(ctx, pollIDs[run], tt.votes); err != nil {
+					t.Fatalf("stop: %v", err)
+				}
+				run++
+			})
+
+			if got := allocs / float64(len(tt.votes)); got > tt.allocsPerVote {
+				t.Errorf("stop used %.2f allocations per vote, budget is %.2f", got, tt.allocsPerVote)
+			}
+		})
+	}
+}
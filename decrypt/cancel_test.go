@@ -0,0 +1,132 @@
+package decrypt_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/OpenSlides/vote-decrypt/decrypt"
+	"go.uber.org/goleak"
+)
+
+// slowCrypto sleeps in each call to Decrypt.
+type slowCrypto struct {
+	cryptoMock
+	delay   time.Duration
+	started chan struct{}
+}
+
+func (c slowCrypto) NewDecrypter(key []byte) (decrypt.Decrypter, error) {
+	return decrypterFunc(func(value []byte) ([]byte, error) {
+		if c.started != nil {
+			select {
+			case c.started <- struct{}{}:
+			default:
+			}
+		}
+		time.Sleep(c.delay)
+		return mockDecrypt(value)
+	}), nil
+}
+
+func manyVotes(n int) [][]byte {
+	votes := make([][]byte, n)
+	for i := range votes {
+		votes[i] = []byte(fmt.Sprintf(`enc:"%d"`, i))
+	}
+	return votes
+}
+
+func TestStopCancel(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	cr := slowCrypto{delay: time.Millisecond, started: make(chan struct{}, 1)}
+	store := NewStoreMock()
+	d := decrypt.New(cr, store)
+
+	if _, _, err := d.Start(context.Background(), "test/1"); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, _, err := d.Stop(ctx, "test/1", manyVotes(10_000))
+		done <- err
+	}()
+
+	<-cr.started
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("stop returned `%v`, expected `%v`", err, context.Canceled)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("stop did not return after the context was canceled")
+	}
+
+	poll, err := store.LoadPoll("test/1")
+	if err != nil {
+		t.Fatalf("loading poll: %v", err)
+	}
+
+	if poll.State != decrypt.StateStarted {
+		t.Errorf("poll has state %s after a canceled stop, expected started", poll.State)
+	}
+}
+
+func TestStopTimeout(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	d := decrypt.New(
+		slowCrypto{delay: time.Millisecond},
+		NewStoreMock(),
+		decrypt.WithStopTimeout(10*time.Millisecond, 0),
+	)
+
+	if _, _, err := d.Start(context.Background(), "test/1"); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	_, _, err := d.Stop(context.Background(), "test/1", manyVotes(10_000))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("stop returned `%v`, expected `%v`", err, context.DeadlineExceeded)
+	}
+}
+
+func TestStopTimeoutPerVote(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	d := decrypt.New(
+		slowCrypto{delay: time.Millisecond},
+		NewStoreMock(),
+		decrypt.WithRandomSource(randomMock{}),
+		decrypt.WithStopTimeout(time.Second, time.Second),
+	)
+
+	if _, _, err := d.Start(context.Background(), "test/1"); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	if _, _, err := d.Stop(context.Background(), "test/1", manyVotes(10)); err != nil {
+		t.Errorf("stop: %v", err)
+	}
+}
+
+func TestStopNoLeak(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	d := decrypt.New(cryptoMock{}, NewStoreMock())
+
+	if _, _, err := d.Start(context.Background(), "test/1"); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	if _, _, err := d.Stop(context.Background(), "test/1", manyVotes(1_000)); err != nil {
+		t.Errorf("stop: %v", err)
+	}
+}
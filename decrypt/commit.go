@@ -0,0 +1,128 @@
+package decrypt
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// maxContributionSize is the maximum size of the public randomness
+// contribution for Stop. Bigger inputs, like a beacon file, have to be hashed
+// by the caller.
+const maxContributionSize = 1 << 10
+
+// seedLabel is used to derive the shuffle seed from the poll key.
+const seedLabel = "vote-decrypt shuffle seed"
+
+// commitmentLabel is the prefix of the message, that is signed for a seed
+// commitment. It makes sure, that the signature can not be mistaken for a
+// signature of a poll key.
+const commitmentLabel = "vote-decrypt seed commitment"
+
+// SeedCommitment returns the commitment to the shuffle seed of a started poll
+// and its signature from the main key. See WithCommitReveal().
+//
+// The commitment is the sha256 digest of the seed. The signature is created
+// over CommitmentMessage(). Returns nil values, if the commit-reveal mode is
+// not enabled.
+func (d *Decrypt) SeedCommitment(ctx context.Context, pollID string) (commitment, signature []byte, err error) {
+	if !d.commitReveal {
+		return nil, nil, nil
+	}
+
+	if err := d.checkNamespace(ctx, pollID); err != nil {
+		return nil, nil, fmt.Errorf("checking tenant: %w", err)
+	}
+
+	pollKey, err := d.store.LoadKey(pollID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("loading poll key: %w", err)
+	}
+
+	digest := sha256.Sum256(shuffleSeed(pollKey))
+	commitment = digest[:]
+	return commitment, d.pollCrypto(pollID).Sign(CommitmentMessage(pollID, commitment)), nil
+}
+
+// CommitmentMessage returns the message, that is signed for the seed
+// commitment of a poll.
+func CommitmentMessage(pollID string, commitment []byte) []byte {
+	msg := make([]byte, 0, len(commitmentLabel)+len(pollID)+len(commitment)+2)
+	msg = append(msg, commitmentLabel...)
+	msg = append(msg, 0)
+	msg = append(msg, pollID...)
+	msg = append(msg, 0)
+	return append(msg, commitment...)
+}
+
+// VerifySeed returns true, if the revealed seed matches the commitment.
+func VerifySeed(seed, commitment []byte) bool {
+	digest := sha256.Sum256(seed)
+	return subtle.ConstantTimeCompare(digest[:], commitment) == 1
+}
+
+// SeededOrder returns the order of the decrypted votes in the commit-reveal
+// mode.
+//
+// The votes are sorted by their bytes and then shuffled with the Fisher–Yates
+// shuffle. The random numbers are read from the stream HMAC-SHA256(k, i) for i
+// = 0, 1, ... as big endian uint64 with k = HMAC-SHA256(seed, contribution).
+//
+// Since the votes are sorted first, the order does not tell, which ciphertext
+// belongs to which vote. The given slice is not modified.
+func SeededOrder(decrypted [][]byte, seed, contribution []byte) ([][]byte, error) {
+	sorted := make([][]byte, len(decrypted))
+	copy(sorted, decrypted)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i], sorted[j]) < 0
+	})
+
+	return shuffle(newSeededSource(seed, contribution), sorted)
+}
+
+// shuffleSeed derives the shuffle seed from the poll key.
+//
+// Revealing the seed does not reveal anything about the poll key.
+func shuffleSeed(pollKey []byte) []byte {
+	mac := hmac.New(sha256.New, pollKey)
+	mac.Write([]byte(seedLabel))
+	return mac.Sum(nil)
+}
+
+// seededSource is a deterministic random source. See SeededOrder().
+type seededSource struct {
+	key     []byte
+	counter uint64
+	buf     []byte
+}
+
+func newSeededSource(seed, contribution []byte) io.Reader {
+	mac := hmac.New(sha256.New, seed)
+	mac.Write(contribution)
+	return &seededSource{key: mac.Sum(nil)}
+}
+
+func (s *seededSource) Read(p []byte) (int, error) {
+	for n := 0; n < len(p); {
+		if len(s.buf) == 0 {
+			var counter [8]byte
+			binary.BigEndian.PutUint64(counter[:], s.counter)
+			s.counter++
+
+			mac := hmac.New(sha256.New, s.key)
+			mac.Write(counter[:])
+			s.buf = mac.Sum(nil)
+		}
+
+		c := copy(p[n:], s.buf)
+		s.buf = s.buf[c:]
+		n += c
+	}
+	return len(p), nil
+}
@@ -0,0 +1,151 @@
+package decrypt_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/OpenSlides/vote-decrypt/decrypt"
+	"github.com/OpenSlides/vote-decrypt/errorcode"
+)
+
+func TestCommitReveal(t *testing.T) {
+	d := decrypt.New(cryptoMock{}, NewStoreMock(), decrypt.WithCommitReveal())
+	contribution := []byte("beacon value")
+
+	if _, _, err := d.Start(context.Background(), "test/1"); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	commitment, commitmentSig, err := d.SeedCommitment(context.Background(), "test/1")
+	if err != nil {
+		t.Fatalf("seed commitment: %v", err)
+	}
+
+	expectedSig := "sig:" + string(decrypt.CommitmentMessage("test/1", commitment))
+	if string(commitmentSig) != expectedSig {
+		t.Errorf("got commitment signature %q, expected %q", commitmentSig, expectedSig)
+	}
+
+	content, _, seed, err := d.StopWithContribution(context.Background(), "test/1", manyVotes(20), contribution)
+	if err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+
+	if !decrypt.VerifySeed(seed, commitment) {
+		t.Errorf("seed does not match the commitment")
+	}
+
+	var decoded struct {
+		Votes []json.RawMessage `json:"votes"`
+	}
+	if err := json.Unmarshal(content, &decoded); err != nil {
+		t.Fatalf("decoding content: %v", err)
+	}
+
+	votes := make([][]byte, len(decoded.Votes))
+	for i, vote := range decoded.Votes {
+		votes[i] = vote
+	}
+
+	recomputed, err := decrypt.SeededOrder(votes, seed, contribution)
+	if err != nil {
+		t.Fatalf("seeded order: %v", err)
+	}
+
+	if !equalVotes(recomputed, votes) {
+		t.Errorf("order of the votes can not be recomputed from the seed")
+	}
+
+	t.Run("second stop with same contribution", func(t *testing.T) {
+		again, _, _, err := d.StopWithContribution(context.Background(), "test/1", manyVotes(20), contribution)
+		if err != nil {
+			t.Fatalf("stop: %v", err)
+		}
+
+		if !bytes.Equal(again, content) {
+			t.Errorf("second stop returned a different content")
+		}
+	})
+
+	t.Run("second stop with other contribution", func(t *testing.T) {
+		_, _, _, err := d.StopWithContribution(context.Background(), "test/1", manyVotes(20), []byte("other"))
+		if err == nil {
+			t.Errorf("stop with other contribution did not fail")
+		}
+	})
+}
+
+func TestCommitRevealDisabled(t *testing.T) {
+	d := decrypt.New(cryptoMock{}, NewStoreMock())
+
+	if _, _, err := d.Start(context.Background(), "test/1"); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	commitment, commitmentSig, err := d.SeedCommitment(context.Background(), "test/1")
+	if err != nil || commitment != nil || commitmentSig != nil {
+		t.Errorf("seed commitment returned (%v, %v, %v), expected nil values", commitment, commitmentSig, err)
+	}
+
+	_, _, _, err = d.StopWithContribution(context.Background(), "test/1", manyVotes(3), []byte("beacon"))
+	if !errors.Is(err, errorcode.Invalid) {
+		t.Errorf("stop returned `%v`, expected `%v`", err, errorcode.Invalid)
+	}
+
+	_, _, seed, err := d.StopWithContribution(context.Background(), "test/1", manyVotes(3), nil)
+	if err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+
+	if seed != nil {
+		t.Errorf("stop returned seed %v, expected nil", seed)
+	}
+}
+
+func TestSeededOrder(t *testing.T) {
+	seed := []byte("seed")
+	votes := [][]byte{[]byte("c"), []byte("a"), []byte("d"), []byte("b"), []byte("a")}
+	reversed := [][]byte{[]byte("a"), []byte("b"), []byte("d"), []byte("a"), []byte("c")}
+
+	first, err := decrypt.SeededOrder(votes, seed, []byte("x"))
+	if err != nil {
+		t.Fatalf("seeded order: %v", err)
+	}
+
+	second, err := decrypt.SeededOrder(reversed, seed, []byte("x"))
+	if err != nil {
+		t.Fatalf("seeded order: %v", err)
+	}
+
+	if !equalVotes(first, second) {
+		t.Errorf("order depends on the input order: %q and %q", first, second)
+	}
+
+	if string(votes[0]) != "c" {
+		t.Errorf("input was modified")
+	}
+
+	other, err := decrypt.SeededOrder(votes, seed, []byte("y"))
+	if err != nil {
+		t.Fatalf("seeded order: %v", err)
+	}
+
+	if equalVotes(first, other) {
+		t.Errorf("contribution does not change the order")
+	}
+}
+
+func equalVotes(a, b [][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !bytes.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
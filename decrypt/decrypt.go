@@ -7,30 +7,54 @@ package decrypt
 import (
 	"context"
 	"crypto/rand"
-	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"math"
-	"math/big"
 	"runtime"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/OpenSlides/vote-decrypt/errorcode"
+	"github.com/OpenSlides/vote-decrypt/metrics"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Decrypt holds the internal state of the decrypt component.
 type Decrypt struct {
-	crypto Crypto
-	store  Store
-
-	maxVotes          int // maximum votes per poll.
-	decryptWorkers    int
-	random            io.Reader
-	listToContent     func(pollID string, decrypted [][]byte) ([]byte, error) // See WithListToContent()
-	decryptErrorValue []byte                                                  // Value to use if a vote can not be decrypted.
+	crypto    Crypto
+	tenants   map[string]Crypto // crypto backends for tenants with an own main key.
+	store     Store
+	tracer    trace.Tracer
+	logger    *slog.Logger
+	auditLogs []AuditLog // optional logs of all operations.
+
+	// transitionMu makes sure, that only one state change is written to the
+	// audit log at the same time. See transition().
+	transitionMu sync.Mutex
+
+	// dummy is the key for unknown polls. See dummyKey().
+	dummyOnce sync.Once
+	dummy     []byte
+	dummyErr  error
+
+	maxVotes           int           // maximum votes per poll.
+	maxVoteSize        int           // maximum size of one encrypted vote. 0 means no limit.
+	stopSlots          chan struct{} // limits the concurrent calls to Stop. nil means no limit.
+	stopTimeoutBase    time.Duration // base timeout for Stop. See WithStopTimeout().
+	stopTimeoutPerVote time.Duration
+	pollTTL            time.Duration // default time to live for a poll. 0 means no limit.
+	decryptWorkers     int           // number of votes, that are decrypted at the same time. See WithDecryptWorkers().
+	scheduler          *scheduler    // distributes the votes of all polls to the workers.
+	random             io.Reader
+	listToContent      func(pollID string, decrypted [][]byte) ([]byte, error) // See WithListToContent()
+	decryptErrorValue  []byte                                                  // Value to use if a vote can not be decrypted.
+	commitReveal       bool                                                    // See WithCommitReveal()
 }
 
 // New returns the initialized decrypt component.
@@ -38,6 +62,8 @@ func New(crypto Crypto, store Store, options ...Option) *Decrypt {
 	d := Decrypt{
 		crypto:            crypto,
 		store:             store,
+		tracer:            otel.Tracer(tracerName),
+		logger:            slog.Default(),
 		decryptWorkers:    runtime.GOMAXPROCS(-1),
 		random:            rand.Reader,
 		maxVotes:          math.MaxInt,
@@ -49,12 +75,24 @@ func New(crypto Crypto, store Store, options ...Option) *Decrypt {
 		o(&d)
 	}
 
+	d.scheduler = newScheduler(d.decryptWorkers)
+
 	return &d
 }
 
-// PublicMainKey returns the public main key.
+// PublicMainKey returns the public main key of the tenant from the context.
 func (d *Decrypt) PublicMainKey(ctx context.Context) []byte {
-	return d.crypto.PublicMainKey()
+	return d.cryptoFor(ctx).PublicMainKey()
+}
+
+// PublicMainKeys returns the public main keys of all tenants with an own main
+// key. The default main key has the tenant "".
+func (d *Decrypt) PublicMainKeys() map[string][]byte {
+	keys := map[string][]byte{"": d.crypto.PublicMainKey()}
+	for tenant, c := range d.tenants {
+		keys[tenant] = c.PublicMainKey()
+	}
+	return keys
 }
 
 // Start starts the poll. Returns a public poll key.
@@ -64,38 +102,108 @@ func (d *Decrypt) PublicMainKey(ctx context.Context) []byte {
 // main key.
 //
 // If the method is called multiple times with the same pollID, it returns the
-// same public key. This is at least true until Clear() is called.
+// same public key. This is at least true until Clear() is called. A poll, that
+// was stopped or is expired can not be started again.
+//
+// The poll expires after the time to live set with WithPollTTL().
 func (d *Decrypt) Start(ctx context.Context, pollID string) (pubKey []byte, pubKeySig []byte, err error) {
+	return d.StartWithTTL(ctx, pollID, 0)
+}
+
+// StartWithTTL is like Start but sets the time to live for the poll. After this
+// time, the poll is expired and its key gets removed.
+//
+// If ttl is 0, the default from WithPollTTL() is used. The ttl is only used,
+// when the poll is created. It is ignored on later calls.
+func (d *Decrypt) StartWithTTL(ctx context.Context, pollID string, ttl time.Duration) (pubKey []byte, pubKeySig []byte, err error) {
+	ctx, span := d.startSpan(ctx, "decrypt.Start", pollID)
+	defer func() { endSpan(span, err) }()
+
 	if err := d.validateID(pollID); err != nil {
 		return nil, nil, fmt.Errorf("invalid poll id: %w", err)
 	}
 
-	// TODO: Load Key and CreatePoll Key have probably be atomic.
-	pollKey, err := d.store.LoadKey(pollID)
+	if err := d.checkNamespace(ctx, pollID); err != nil {
+		return nil, nil, fmt.Errorf("checking tenant: %w", err)
+	}
+
+	cr := d.pollCrypto(pollID)
+
+	poll, err := d.store.LoadPoll(pollID)
+	if err != nil && !errors.Is(err, errorcode.NotExist) {
+		return nil, nil, fmt.Errorf("loading poll: %w", err)
+	}
+
+	if poll.IsExpired(time.Now()) {
+		return nil, nil, fmt.Errorf("poll is expired: %w", errorcode.WrongState)
+	}
+
+	if poll.State == StateStopped {
+		return nil, nil, fmt.Errorf("poll is stopped: %w", errorcode.WrongState)
+	}
+
+	var pollKey []byte
+	err = d.inSpan(ctx, "load key", func(ctx context.Context) error {
+		pollKey, err = d.loadOrCreateKey(cr, pollID, ttl)
+		return err
+	})
 	if err != nil {
-		if !errors.Is(err, errorcode.NotExist) {
-			return nil, nil, fmt.Errorf("loading poll key: %w", err)
-		}
+		return nil, nil, fmt.Errorf("loading key: %w", err)
+	}
 
-		key, err := d.crypto.CreatePollKey()
-		if err != nil {
-			return nil, nil, fmt.Errorf("creating poll key: %w", err)
+	err = d.inSpan(ctx, "sign public key", func(ctx context.Context) error {
+		pubKey, pubKeySig, err = cr.PublicPollKey(pollKey)
+		return err
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("signing pub key: %w", err)
+	}
+
+	if poll.State != StateStarted {
+		event := AuditEvent{
+			Operation:       AuditStart,
+			PollID:          pollID,
+			PublicKey:       pubKey,
+			ResultSignature: pubKeySig,
 		}
 
-		pollKey = key
-		if err := d.store.SaveKey(pollID, key); err != nil {
-			return nil, nil, fmt.Errorf("saving poll key: %w", err)
+		// The store only sets the state, if the poll was not stopped in the
+		// meantime.
+		err := d.transition(ctx, StateStarted, event, func() error {
+			return d.store.SetState(pollID, StateStarted)
+		})
+		if err != nil {
+			return nil, nil, err
 		}
 	}
 
-	pubKey, pubKeySig, err = d.crypto.PublicPollKey(pollKey)
+	d.logger.InfoContext(ctx, "poll started", "poll_id", pollID)
+	return pubKey, pubKeySig, nil
+}
+
+// loadOrCreateKey returns the key of the poll. If the poll has no key, a new
+// key is created and saved with the expire time from the ttl.
+func (d *Decrypt) loadOrCreateKey(cr Crypto, pollID string, ttl time.Duration) ([]byte, error) {
+	key, created, err := d.store.LoadOrCreateKey(pollID, cr.CreatePollKey)
 	if err != nil {
-		return nil, nil, fmt.Errorf("signing pub key: %w", err)
+		return nil, fmt.Errorf("loading or creating poll key: %w", err)
 	}
 
-	// Log the pubKey as base64 as long as the backend does not support his
-	log.Printf("public poll key for poll %s is %s", pollID, base64.StdEncoding.EncodeToString(pubKey))
-	return pubKey, pubKeySig, nil
+	if !created {
+		return key, nil
+	}
+
+	if ttl == 0 {
+		ttl = d.pollTTL
+	}
+
+	if ttl > 0 {
+		if err := d.store.SetExpires(pollID, time.Now().Add(ttl)); err != nil {
+			return nil, fmt.Errorf("setting expire time: %w", err)
+		}
+	}
+
+	return key, nil
 }
 
 // Stop takes a list of ecrypted votes, decryptes them and returns them in a
@@ -107,122 +215,372 @@ func (d *Decrypt) Start(ctx context.Context, pollID string) (pubKey []byte, pubK
 //
 // TODO: This implementation is wrong. Not the output has to be hashed and saved, but the input.
 func (d *Decrypt) Stop(ctx context.Context, pollID string, voteList [][]byte) (decryptedContent, signature []byte, err error) {
-	pollKey, err := d.store.LoadKey(pollID)
+	decryptedContent, signature, _, err = d.StopWithContribution(ctx, pollID, voteList, nil)
+	return decryptedContent, signature, err
+}
+
+// StopWithContribution is like Stop but accepts a public randomness
+// contribution for the commit-reveal mode. See WithCommitReveal().
+//
+// In this mode, the order of the votes is created with SeededOrder() and the
+// seed is returned. It can be checked with VerifySeed() against the
+// commitment from SeedCommitment(). Without the mode, the seed is nil and the
+// contribution has to be empty.
+func (d *Decrypt) StopWithContribution(ctx context.Context, pollID string, voteList [][]byte, contribution []byte) (decryptedContent, signature, seed []byte, err error) {
+	ctx, span := d.startSpan(ctx, "decrypt.Stop", pollID)
+	defer func() { endSpan(span, err) }()
+	span.SetAttributes(attribute.Int("poll.votes", len(voteList)))
+
+	if err := d.checkNamespace(ctx, pollID); err != nil {
+		return nil, nil, nil, fmt.Errorf("checking tenant: %w", err)
+	}
+
+	if len(contribution) > 0 && !d.commitReveal {
+		return nil, nil, nil, fmt.Errorf("contribution without commit-reveal mode: %w", errorcode.Invalid)
+	}
+
+	if len(contribution) > maxContributionSize {
+		return nil, nil, nil, fmt.Errorf("contribution is bigger than %d bytes: %w", maxContributionSize, errorcode.Invalid)
+	}
+
+	if err := d.checkLimits(voteList); err != nil {
+		return nil, nil, nil, err
+	}
+
+	release, err := d.acquireStop()
 	if err != nil {
-		return nil, nil, fmt.Errorf("loading poll key: %w", err)
+		return nil, nil, nil, err
 	}
+	defer release()
 
-	if len(voteList) > d.maxVotes {
-		return nil, nil, fmt.Errorf("received %d votes, only %d votes supported: %w", len(voteList), d.maxVotes, errorcode.Invalid)
+	if timeout := d.stopTimeout(len(voteList)); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
 	}
 
-	decrypted, err := d.decryptVotes(pollKey, voteList)
+	cr := d.pollCrypto(pollID)
+
+	// Unknown and cleared polls run all steps with a dummy key and only fail
+	// at the end. So the duration of Stop does not tell, if the poll exists.
+	// This only helps, if the store has uniform timing. See Store.
+	var notExist error
+
+	poll, err := d.store.LoadPoll(pollID)
 	if err != nil {
-		return nil, nil, fmt.Errorf("decrypting votes: %w", err)
+		if !errors.Is(err, errorcode.NotExist) {
+			return nil, nil, nil, fmt.Errorf("loading poll: %w", err)
+		}
+		notExist = fmt.Errorf("loading poll: %w", err)
 	}
 
-	decryptedContent, err = d.listToContent(pollID, decrypted)
+	if notExist == nil {
+		if poll.IsExpired(time.Now()) {
+			return nil, nil, nil, fmt.Errorf("poll is expired: %w", errorcode.WrongState)
+		}
+
+		switch poll.State {
+		case StateCreated:
+			return nil, nil, nil, fmt.Errorf("poll is not started: %w", errorcode.WrongState)
+		case StateCleared:
+			notExist = fmt.Errorf("poll was cleared: %w", errorcode.NotExist)
+		}
+	}
+
+	var pollKey []byte
+	err = d.inSpan(ctx, "load key", func(ctx context.Context) error {
+		pollKey, err = d.store.LoadKey(pollID)
+		if notExist != nil {
+			pollKey, err = d.dummyKey()
+		}
+		return err
+	})
 	if err != nil {
-		return nil, nil, fmt.Errorf("creating content: %w", err)
+		return nil, nil, nil, fmt.Errorf("loading poll key: %w", err)
 	}
 
-	signature = d.crypto.Sign(decryptedContent)
+	inputDigest := VotesDigest(voteList)
+
+	var decrypted [][]byte
+	err = d.inSpan(ctx, "decrypt votes", func(ctx context.Context) error {
+		decryptStart := time.Now()
+		var failed int
+		decrypted, failed, err = d.decryptVotes(ctx, cr, pollKey, voteList)
+		if err != nil {
+			return err
+		}
+
+		if notExist != nil {
+			return nil
+		}
+
+		if failed > 0 {
+			d.logger.WarnContext(ctx, "votes could not be decrypted", "poll_id", pollID, "failed_votes", failed)
+		}
+		metrics.DecryptDuration.WithLabelValues(metrics.PollSize(len(voteList))).Observe(time.Since(decryptStart).Seconds())
+		metrics.VotesDecrypted.Add(float64(len(voteList)))
+		metrics.DecryptFailures.Add(float64(failed))
+		return nil
+	})
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("decrypting votes: %w", err)
+	}
+
+	if d.commitReveal {
+		seed = shuffleSeed(pollKey)
+		decrypted, err = SeededOrder(decrypted, seed, contribution)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("ordering votes with seed: %w", err)
+		}
+	}
+
+	err = d.inSpan(ctx, "build content", func(ctx context.Context) error {
+		decryptedContent, err = d.listToContent(pollID, decrypted)
+		return err
+	})
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("creating content: %w", err)
+	}
+
+	d.inSpan(ctx, "sign content", func(ctx context.Context) error {
+		signature = cr.Sign(decryptedContent)
+		return nil
+	})
+
+	if notExist != nil {
+		// ValidateSignature would save the signature of the dummy key, if the
+		// poll was started in the meantime. LoadKey takes a similar time.
+		d.store.LoadKey(pollID)
+		return nil, nil, nil, notExist
+	}
+
+	event := AuditEvent{
+		Operation:       AuditStop,
+		PollID:          pollID,
+		Votes:           len(voteList),
+		InputDigest:     inputDigest,
+		ResultSignature: signature,
+	}
 
 	// This has to be the last step of this function to protect agains timing
 	// attacks. All other steps have to be run, even when the calll is doomed to
 	// fail in this step
-	if err := d.store.ValidateSignature(pollID, signature); err != nil {
+	err = d.inSpan(ctx, "validate signature", func(ctx context.Context) error {
+		return d.saveStopResult(ctx, poll.State, event)
+	})
+	if err != nil {
 		if errors.Is(err, errorcode.Invalid) {
-			return nil, nil, fmt.Errorf("stop was called with different parameters before")
+			return nil, nil, nil, fmt.Errorf("stop was called with different parameters before")
 		}
-		return nil, nil, fmt.Errorf("validate signature: %w", err)
+		return nil, nil, nil, err
 	}
 
-	return decryptedContent, signature, nil
+	return decryptedContent, signature, seed, nil
+}
+
+// saveStopResult saves the signature of the stop result and sets the poll to
+// the state stopped.
+//
+// If the store implements StopResultSaver, the signature and the state are
+// saved in one call after the audit entry was written. Otherwise, the
+// signature is saved first.
+func (d *Decrypt) saveStopResult(ctx context.Context, state PollState, event AuditEvent) error {
+	saver, ok := d.store.(StopResultSaver)
+	if !ok {
+		if err := d.store.ValidateSignature(event.PollID, event.ResultSignature); err != nil {
+			return fmt.Errorf("validate signature: %w", err)
+		}
+
+		if state == StateStopped {
+			return nil
+		}
+
+		return d.transition(ctx, StateStopped, event, func() error {
+			return d.store.SetState(event.PollID, StateStopped)
+		})
+	}
+
+	if state != StateStopped {
+		var saved bool
+		err := d.transition(ctx, StateStopped, event, func() error {
+			saved = true
+			return saver.SaveStopResult(event.PollID, event.ResultSignature)
+		})
+		if err != nil || saved {
+			return err
+		}
+	}
+
+	// The poll was already stopped. SaveStopResult only compares the
+	// signature.
+	if err := saver.SaveStopResult(event.PollID, event.ResultSignature); err != nil {
+		return fmt.Errorf("save stop result: %w", err)
+	}
+	return nil
 }
 
 // Clear stops a poll by removing the generated cryptographic key.
+//
+// It can be called in any state of the poll. Afterwards, the poll can be
+// started again with a new key.
+//
+// The audit entry is written and the store is called for every poll, also for
+// unknown and cleared ones. So the duration of Clear does not tell, if the poll
+// exists. The entry is written first, so the key is never removed without it.
 func (d *Decrypt) Clear(ctx context.Context, pollID string) error {
+	if err := d.checkNamespace(ctx, pollID); err != nil {
+		return fmt.Errorf("checking tenant: %w", err)
+	}
+
+	// The lock keeps the order of the audit log the same as the order of the
+	// state changes. See transition().
+	d.transitionMu.Lock()
+	defer d.transitionMu.Unlock()
+
+	if err := d.audit(ctx, AuditEvent{Operation: AuditClear, PollID: pollID}); err != nil {
+		return err
+	}
+
 	if err := d.store.ClearPoll(pollID); err != nil {
 		return fmt.Errorf("clearing poll from store: %w", err)
 	}
+
 	return nil
 }
 
-// randInt returns a random int between 0 and n from a random source like crypt.Reader
-func randInt(source io.Reader, n int) (int, error) {
-	if n <= 0 {
-		return 0, nil
+// dummyKey returns a poll key, that is used instead of the key of an unknown
+// or cleared poll. It is created on the first call.
+func (d *Decrypt) dummyKey() ([]byte, error) {
+	d.dummyOnce.Do(func() {
+		d.dummy, d.dummyErr = d.crypto.CreatePollKey()
+	})
+	return d.dummy, d.dummyErr
+}
+
+// GetPoll returns the state and meta data of a poll.
+//
+// Returns `errorcode.NotExist` if the poll is unknown.
+func (d *Decrypt) GetPoll(ctx context.Context, pollID string) (Poll, error) {
+	if err := d.checkNamespace(ctx, pollID); err != nil {
+		return Poll{}, fmt.Errorf("checking tenant: %w", err)
 	}
 
-	r, err := rand.Int(source, big.NewInt(int64(n)))
+	poll, err := d.store.LoadPoll(pollID)
 	if err != nil {
-		return 0, fmt.Errorf("getting random number from rand.Int: %w", err)
+		return Poll{}, fmt.Errorf("loading poll: %w", err)
 	}
+	return poll, nil
+}
 
-	return int(r.Int64()), nil
+// ListPolls returns the meta data of all polls known by the store.
+//
+// If the context has a tenant, only the polls of the tenant are returned.
+func (d *Decrypt) ListPolls(ctx context.Context) ([]Poll, error) {
+	polls, err := d.store.ListPolls()
+	if err != nil {
+		return nil, fmt.Errorf("listing polls: %w", err)
+	}
+
+	if TenantFromContext(ctx) == "" {
+		return polls, nil
+	}
+
+	filtered := polls[:0]
+	for _, poll := range polls {
+		if d.checkNamespace(ctx, poll.ID) == nil {
+			filtered = append(filtered, poll)
+		}
+	}
+	return filtered, nil
+}
+
+// Health returns an error, if the service is not able to handle requests.
+//
+// It checks, that the main keys are loaded and that the store is reachable. If
+// the store implements StoreHealthChecker, it also checks, that the store is
+// writable.
+func (d *Decrypt) Health(ctx context.Context) error {
+	if len(d.crypto.PublicMainKey()) == 0 {
+		return fmt.Errorf("no main key loaded")
+	}
+
+	for tenant, c := range d.tenants {
+		if len(c.PublicMainKey()) == 0 {
+			return fmt.Errorf("no main key loaded for tenant %s", tenant)
+		}
+	}
+
+	if checker, ok := d.store.(StoreHealthChecker); ok {
+		if err := checker.Health(); err != nil {
+			return fmt.Errorf("store not ready: %w", err)
+		}
+	}
+
+	if _, err := d.store.ListPolls(); err != nil {
+		return fmt.Errorf("store not reachable: %w", err)
+	}
+
+	return nil
 }
 
 // decryptVotes decrypts a list of votes and returns them decrypted in random
 // order.
 //
-// Uses `d.decrptWorkers` parallel goroutines.
-func (d *Decrypt) decryptVotes(key []byte, voteList [][]byte) ([][]byte, error) {
-	voteChan := make(chan []byte, 1)
+// The poll key is parsed once and the order is computed before the decryption.
+// The votes are decrypted by the workers of the scheduler. Each worker
+// decrypts a range of the output slice. All workers are stopped, when the
+// context is done. The function returns after all workers have finished.
+//
+// Votes, that can not be decrypted, are replaced with d.decryptErrorValue. The
+// number of these votes is returned. The reason is not returned, since it
+// could tell something about the content of the vote.
+func (d *Decrypt) decryptVotes(ctx context.Context, cr Crypto, key []byte, voteList [][]byte) ([][]byte, int, error) {
+	decrypter, err := cr.NewDecrypter(key)
+	if err != nil {
+		return nil, 0, CryptoError{Err: fmt.Errorf("preparing poll key: %w", err)}
+	}
 
-	// Choose a random vote from the voteList and sends them to voteChan.
-	go func() {
-		defer close(voteChan)
+	perm, err := permutation(d.random, len(voteList))
+	if err != nil {
+		return nil, 0, RandomSourceError{Err: err}
+	}
 
-		n := len(voteList)
-		for n > 0 {
-			i, err := randInt(d.random, n-1)
-			if err != nil {
-				// TODO: handle error
-				panic(err)
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	decryptedList := make([][]byte, len(voteList))
+	var failed atomic.Int64
+
+	d.scheduler.run(ctx, PriorityFromContext(ctx), len(voteList), func(from, to int) {
+		for i := from; i < to; i++ {
+			if ctx.Err() != nil {
+				return
 			}
 
-			voteChan <- voteList[i]
-			voteList[i] = voteList[n-1]
-			n--
-		}
-	}()
-
-	// Decrypt votes in parallel using multiple "decrypt workers". Receiving the
-	// votes from voteChan and sending them to decryptedChan.
-	var wg sync.WaitGroup
-	wg.Add(d.decryptWorkers)
-	decryptedChan := make(chan []byte, 1)
-	for i := 0; i < d.decryptWorkers; i++ {
-		go func() {
-			defer wg.Done()
-			for vote := range voteChan {
-				decrypted, err := d.crypto.Decrypt(key, vote)
-				if err != nil {
-					// TODO: Is is allowed to log the error?
-					log.Printf("TODO: vote: %v", err)
-					decrypted = d.decryptErrorValue
+			decrypted, err := decrypter.Decrypt(voteList[perm[i]])
+			if err != nil {
+				if !errors.Is(err, errorcode.Invalid) {
+					cancel(CryptoError{Err: err})
+					return
 				}
 
-				decryptedChan <- decrypted
+				failed.Add(1)
+				decrypted = d.decryptErrorValue
 			}
-		}()
-	}
 
-	// Close the decryptedChan when all the decryption is done.
-	go func() {
-		wg.Wait()
-		close(decryptedChan)
-	}()
+			decryptedList[i] = decrypted
+		}
+	})
 
-	// Bundle decrypted votes.
-	decryptedList := make([][]byte, len(voteList))
-	var i int
-	for decrypted := range decryptedChan {
-		decryptedList[i] = decrypted
-		i++
+	if err := context.Cause(ctx); err != nil {
+		var cryptoErr CryptoError
+		if errors.As(err, &cryptoErr) {
+			return nil, 0, err
+		}
+		return nil, 0, fmt.Errorf("aborted: %w", err)
 	}
-	return decryptedList, nil
+
+	return decryptedList, int(failed.Load()), nil
 }
 
 // validateID makes sure, the id can be used for the filesystem store.
@@ -246,8 +604,9 @@ type Crypto interface {
 	// PublicPollKey returns the public poll key and the signature for a given key.
 	PublicPollKey(key []byte) (pubKey []byte, pubKeySig []byte, err error)
 
-	// Decrypt returned the plaintext from value using the key.
-	Decrypt(key []byte, value []byte) ([]byte, error)
+	// NewDecrypter parses the private poll key and returns an object to
+	// decrypt the votes of the poll.
+	NewDecrypter(key []byte) (Decrypter, error)
 
 	// Sign returns the signature for the given data.
 	Sign(value []byte) []byte
@@ -256,11 +615,34 @@ type Crypto interface {
 	PublicMainKey() []byte
 }
 
+// Decrypter decrypts votes with a prepared poll key.
+//
+// It has to be safe for concurrent use.
+type Decrypter interface {
+	// Decrypt returned the plaintext from value.
+	//
+	// If the value is not a valid ciphertext for the key, the error has to
+	// wrap `errorcode.Invalid`. The vote is then counted as invalid. All
+	// other errors abort the decryption of the poll.
+	Decrypt(value []byte) ([]byte, error)
+}
+
 // Store saves the data, that have to be persistent.
+//
+// The package store/storetest tests, that an implementation fulfills this
+// contract.
+//
+// Uniform timing is not part of the contract. Only the file store from the
+// package store makes the calls for unknown, cleared and known polls take the
+// same time. With other stores, the duration of Stop and Clear can tell, if a
+// poll exists.
 type Store interface {
 	// SaveKey stores the private key.
 	//
 	// Has to return an error `errorcode.Exist` if the key is already known.
+	//
+	// The poll is set to the state StateCreated. Meta data from a cleared poll
+	// with the same id is replaced.
 	SaveKey(id string, key []byte) error
 
 	// LoadKey returns the private key from the store.
@@ -268,6 +650,14 @@ type Store interface {
 	// If the poll is unknown return `errorcode.NotExist`
 	LoadKey(id string) (key []byte, err error)
 
+	// LoadOrCreateKey returns the private key of the poll. If the poll has no
+	// key, it calls create and saves the new key like SaveKey.
+	//
+	// Has to be atomic. If it is called concurrently for the same id, create
+	// is called at most once and all calls return the same key. created is
+	// true for the call, that saved the key.
+	LoadOrCreateKey(id string, create func() ([]byte, error)) (key []byte, created bool, err error)
+
 	// ValidateSignature makes sure, that no other signature is saved for a
 	// poll. Saves the signature for future calls.
 	//
@@ -277,10 +667,55 @@ type Store interface {
 	// Has to return `errorcode.NotExist` when the id does not exist.
 	ValidateSignature(id string, hash []byte) error
 
-	// ClearPoll removes all data for the poll.
+	// ClearPoll removes all data for the poll except its meta data. The poll
+	// is set to StateCleared.
 	//
 	// Does not return an error if poll does not exist.
 	ClearPoll(id string) error
+
+	// LoadPoll returns the meta data of a poll.
+	//
+	// Has to return `errorcode.NotExist` when the id does not exist.
+	LoadPoll(id string) (Poll, error)
+
+	// SetState sets the state of a poll and the timestamp for the new state.
+	//
+	// A poll can only be set to StateStarted, if its current state is
+	// StateCreated or StateStarted (see PollState.CanStart). Otherwise
+	// `errorcode.WrongState` has to be returned. The check and the change have
+	// to be atomic, so a concurrent Stop can not be reverted.
+	//
+	// Has to return `errorcode.NotExist` when the id does not exist.
+	SetState(id string, state PollState) error
+
+	// SetExpires sets the time, when the poll expires.
+	//
+	// Has to return `errorcode.NotExist` when the id does not exist.
+	SetExpires(id string, expires time.Time) error
+
+	// ListPolls returns the meta data of all known polls including cleared
+	// and expired polls.
+	ListPolls() ([]Poll, error)
+}
+
+// StopResultSaver can be implemented by a Store to save the result of Stop in
+// one transaction.
+//
+// Stop calls it instead of ValidateSignature and SetState(id, StateStopped)
+// after the stop was written to the audit log. So the signature is not saved,
+// if the audit log fails. For an already stopped poll, Stop calls it only to
+// compare the signature.
+type StopResultSaver interface {
+	// SaveStopResult works like ValidateSignature followed by
+	// SetState(id, StateStopped). The state is only changed, if the signature
+	// is valid and the poll is not stopped yet.
+	SaveStopResult(id string, signature []byte) error
+}
+
+// StoreHealthChecker can be implemented by a Store to report, if it is ready.
+type StoreHealthChecker interface {
+	// Health returns an error, if the store can not read or write data.
+	Health() error
 }
 
 // jsonListToContent creates one byte slice from a list of votes in json format.
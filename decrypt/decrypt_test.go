@@ -3,15 +3,20 @@ package decrypt_test
 import (
 	"bytes"
 	"context"
+	"crypto/rand"
 	"errors"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/OpenSlides/vote-decrypt/crypto"
 	"github.com/OpenSlides/vote-decrypt/decrypt"
 	"github.com/OpenSlides/vote-decrypt/errorcode"
+	"github.com/OpenSlides/vote-decrypt/metrics"
+	"github.com/OpenSlides/vote-decrypt/store"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
-// TODO: test concurency.
-
 func TestStart(t *testing.T) {
 	cr := cryptoMock{}
 	store := NewStoreMock()
@@ -49,6 +54,83 @@ func TestStart(t *testing.T) {
 	})
 }
 
+func TestStartConcurrent(t *testing.T) {
+	const starts = 300
+
+	for _, tt := range []struct {
+		name  string
+		store decrypt.Store
+	}{
+		{"mock", NewStoreMock()},
+		{"file store", store.New(t.TempDir())},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			d := decrypt.New(crypto.New(make([]byte, 32), rand.Reader, nil), tt.store)
+
+			var wg sync.WaitGroup
+			pubKeys := make([][]byte, starts)
+			errs := make([]error, starts)
+			ready := make(chan struct{})
+			for i := 0; i < starts; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					<-ready
+					pubKeys[i], _, errs[i] = d.Start(context.Background(), "test/1")
+				}(i)
+			}
+			close(ready)
+			wg.Wait()
+
+			for i := 0; i < starts; i++ {
+				if errs[i] != nil {
+					t.Fatalf("start %d: %v", i, errs[i])
+				}
+
+				if !bytes.Equal(pubKeys[i], pubKeys[0]) {
+					t.Fatalf("start %d returned a different public key", i)
+				}
+			}
+		})
+	}
+}
+
+// stopBetweenStore is a store, that stops the poll after LoadPoll, like a
+// concurrent call to Stop.
+type stopBetweenStore struct {
+	*StoreMock
+}
+
+func (s stopBetweenStore) LoadPoll(id string) (decrypt.Poll, error) {
+	poll, err := s.StoreMock.LoadPoll(id)
+	if err != nil {
+		return poll, err
+	}
+	return poll, s.StoreMock.SetState(id, decrypt.StateStopped)
+}
+
+func TestStartConcurrentStop(t *testing.T) {
+	store := stopBetweenStore{NewStoreMock()}
+	if err := store.SaveKey("test/1", []byte("pollKey")); err != nil {
+		t.Fatalf("SaveKey: %v", err)
+	}
+
+	d := decrypt.New(cryptoMock{}, store)
+
+	if _, _, err := d.Start(context.Background(), "test/1"); !errors.Is(err, errorcode.WrongState) {
+		t.Errorf("start returned %v, expected %v", err, errorcode.WrongState)
+	}
+
+	poll, err := store.StoreMock.LoadPoll("test/1")
+	if err != nil {
+		t.Fatalf("LoadPoll: %v", err)
+	}
+
+	if poll.State != decrypt.StateStopped {
+		t.Errorf("poll has state %s, expected %s", poll.State, decrypt.StateStopped)
+	}
+}
+
 func TestStop(t *testing.T) {
 	cr := cryptoMock{}
 
@@ -75,7 +157,7 @@ func TestStop(t *testing.T) {
 			t.Errorf("got signature %s, expected signature %s", signature, "sig:"+string(content))
 		}
 
-		expected := `{"id":"test/1","votes":["Y","A","N"]}`
+		expected := `{"id":"test/1","votes":["N","A","Y"]}`
 		if string(content) != expected {
 			t.Errorf("got %s, expected %s", content, expected)
 		}
@@ -104,7 +186,7 @@ func TestStop(t *testing.T) {
 			t.Errorf("got signature %s, expected signature %s", signature, "sig:"+string(content))
 		}
 
-		expected := `{"id":"test/1","votes":["Y","A",{"error":"encryption not valid"}]}`
+		expected := `{"id":"test/1","votes":[{"error":"encryption not valid"},"A","Y"]}`
 		if string(content) != expected {
 			t.Errorf("got %s, expected %s", content, expected)
 		}
@@ -146,8 +228,8 @@ func TestStop(t *testing.T) {
 		}
 
 		_, _, err := d.Stop(context.Background(), "test/1", votes)
-		if !errors.Is(err, errorcode.Invalid) {
-			t.Errorf("stop returned `%v` expected `%v`", err, errorcode.Invalid)
+		if !errors.Is(err, errorcode.Exhausted) {
+			t.Errorf("stop returned `%v` expected `%v`", err, errorcode.Exhausted)
 		}
 	})
 
@@ -183,13 +265,161 @@ func TestStop(t *testing.T) {
 			t.Errorf("got signature %s, expected signature %s", signature, "sig:"+string(content))
 		}
 
-		expected := `"Y","A","N"`
+		expected := `"N","A","Y"`
 		if string(content) != expected {
 			t.Errorf("got %s, expected %s", content, expected)
 		}
 	})
 }
 
+// stopResultStore is a store, that saves the stop result in one call.
+type stopResultStore struct {
+	*StoreMock
+	saved     int
+	validated int
+}
+
+// ValidateSignature counts the calls. Stop should use SaveStopResult instead.
+func (s *stopResultStore) ValidateSignature(id string, signature []byte) error {
+	s.mu.Lock()
+	s.validated++
+	s.mu.Unlock()
+	return s.StoreMock.ValidateSignature(id, signature)
+}
+
+// SaveStopResult validates the signature and sets the state to stopped in one
+// step. The state and the stopped time are not changed, if the poll is
+// already stopped.
+func (s *stopResultStore) SaveStopResult(id string, signature []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.saved++
+	if err := s.validateSignature(id, signature); err != nil {
+		return err
+	}
+
+	poll := s.polls[id]
+	if poll.State == decrypt.StateStopped {
+		return nil
+	}
+
+	poll.State = decrypt.StateStopped
+	poll.SetTime(decrypt.StateStopped, time.Now())
+	s.polls[id] = poll
+	return nil
+}
+
+func TestStopResultSaver(t *testing.T) {
+	store := &stopResultStore{StoreMock: NewStoreMock()}
+	d := decrypt.New(cryptoMock{}, store, decrypt.WithRandomSource(randomMock{}))
+
+	if _, _, err := d.Start(context.Background(), "test/1"); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	var stopped time.Time
+	for i := 0; i < 2; i++ {
+		if _, _, err := d.Stop(context.Background(), "test/1", [][]byte{[]byte(`enc:"Y"`)}); err != nil {
+			t.Fatalf("stop %d: %v", i+1, err)
+		}
+
+		poll, err := store.LoadPoll("test/1")
+		if err != nil {
+			t.Fatalf("LoadPoll: %v", err)
+		}
+
+		if poll.State != decrypt.StateStopped {
+			t.Errorf("poll has state %s, expected %s", poll.State, decrypt.StateStopped)
+		}
+
+		if i == 0 {
+			stopped = poll.Stopped
+			time.Sleep(time.Millisecond)
+			continue
+		}
+
+		if !poll.Stopped.Equal(stopped) {
+			t.Errorf("second stop changed the stopped time from %v to %v", stopped, poll.Stopped)
+		}
+	}
+
+	if store.saved != 2 {
+		t.Errorf("SaveStopResult was called %d times, expected twice", store.saved)
+	}
+
+	if store.validated != 0 {
+		t.Errorf("ValidateSignature was called %d times, expected SaveStopResult instead", store.validated)
+	}
+
+	if _, _, err := d.Stop(context.Background(), "test/1", [][]byte{[]byte(`enc:"N"`)}); err == nil {
+		t.Errorf("stop with other votes did not return an error")
+	}
+}
+
+func TestStopMetrics(t *testing.T) {
+	d := decrypt.New(cryptoMock{}, NewStoreMock(), decrypt.WithRandomSource(randomMock{}))
+
+	if _, _, err := d.Start(context.Background(), "test/1"); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	decrypted := testutil.ToFloat64(metrics.VotesDecrypted)
+	failures := testutil.ToFloat64(metrics.DecryptFailures)
+
+	votes := [][]byte{
+		[]byte(`enc:"Y"`),
+		[]byte(`encwrong:"N"`),
+	}
+
+	if _, _, err := d.Stop(context.Background(), "test/1", votes); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+
+	if got := testutil.ToFloat64(metrics.VotesDecrypted) - decrypted; got != 2 {
+		t.Errorf("votes decrypted increased by %f, expected 2", got)
+	}
+
+	if got := testutil.ToFloat64(metrics.DecryptFailures) - failures; got != 1 {
+		t.Errorf("decrypt failures increased by %f, expected 1", got)
+	}
+}
+
+func TestStopUnknownPoll(t *testing.T) {
+	store := NewStoreMock()
+	d := decrypt.New(cryptoMock{}, store, decrypt.WithRandomSource(randomMock{}))
+
+	if _, _, err := d.Start(context.Background(), "test/cleared"); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	if err := d.Clear(context.Background(), "test/cleared"); err != nil {
+		t.Fatalf("clear: %v", err)
+	}
+
+	decrypted := testutil.ToFloat64(metrics.VotesDecrypted)
+	failures := testutil.ToFloat64(metrics.DecryptFailures)
+
+	for _, pollID := range []string{"test/unknown", "test/cleared"} {
+		_, _, err := d.Stop(context.Background(), pollID, [][]byte{[]byte(`enc:"Y"`)})
+		if !errors.Is(err, errorcode.NotExist) {
+			t.Errorf("stop %s returned `%v`, expected `%v`", pollID, err, errorcode.NotExist)
+		}
+
+		if err := store.ValidateSignature(pollID, []byte("sig")); !errors.Is(err, errorcode.NotExist) {
+			t.Errorf("stop %s created the poll", pollID)
+		}
+	}
+
+	if got := testutil.ToFloat64(metrics.VotesDecrypted) - decrypted; got != 0 {
+		t.Errorf("votes decrypted increased by %f for unknown polls", got)
+	}
+
+	if got := testutil.ToFloat64(metrics.DecryptFailures) - failures; got != 0 {
+		t.Errorf("decrypt failures increased by %f for unknown polls", got)
+	}
+}
+
 func TestClear(t *testing.T) {
 	cr := cryptoMock{}
 	store := NewStoreMock()
@@ -199,3 +429,126 @@ func TestClear(t *testing.T) {
 		t.Fatalf("clear: %v", err)
 	}
 }
+
+func TestLifecycle(t *testing.T) {
+	cr := cryptoMock{}
+	votes := [][]byte{[]byte(`enc:"Y"`)}
+
+	t.Run("start sets state", func(t *testing.T) {
+		d := decrypt.New(cr, NewStoreMock())
+
+		if _, _, err := d.Start(context.Background(), "test/1"); err != nil {
+			t.Fatalf("start: %v", err)
+		}
+
+		poll, err := d.GetPoll(context.Background(), "test/1")
+		if err != nil {
+			t.Fatalf("get poll: %v", err)
+		}
+
+		if poll.State != decrypt.StateStarted {
+			t.Errorf("got state %s, expected %s", poll.State, decrypt.StateStarted)
+		}
+
+		if poll.Created.IsZero() || poll.Started.IsZero() {
+			t.Errorf("timestamps are not set: %v", poll)
+		}
+	})
+
+	t.Run("stop sets state", func(t *testing.T) {
+		d := decrypt.New(cr, NewStoreMock(), decrypt.WithRandomSource(randomMock{}))
+
+		if _, _, err := d.Start(context.Background(), "test/1"); err != nil {
+			t.Fatalf("start: %v", err)
+		}
+
+		if _, _, err := d.Stop(context.Background(), "test/1", votes); err != nil {
+			t.Fatalf("stop: %v", err)
+		}
+
+		poll, err := d.GetPoll(context.Background(), "test/1")
+		if err != nil {
+			t.Fatalf("get poll: %v", err)
+		}
+
+		if poll.State != decrypt.StateStopped {
+			t.Errorf("got state %s, expected %s", poll.State, decrypt.StateStopped)
+		}
+
+		if _, _, err := d.Stop(context.Background(), "test/1", votes); err != nil {
+			t.Errorf("second stop: %v", err)
+		}
+	})
+
+	t.Run("start after stop", func(t *testing.T) {
+		d := decrypt.New(cr, NewStoreMock(), decrypt.WithRandomSource(randomMock{}))
+
+		if _, _, err := d.Start(context.Background(), "test/1"); err != nil {
+			t.Fatalf("start: %v", err)
+		}
+
+		if _, _, err := d.Stop(context.Background(), "test/1", votes); err != nil {
+			t.Fatalf("stop: %v", err)
+		}
+
+		_, _, err := d.Start(context.Background(), "test/1")
+		if !errors.Is(err, errorcode.WrongState) {
+			t.Errorf("start returned `%v`, expected `%v`", err, errorcode.WrongState)
+		}
+	})
+
+	t.Run("stop after clear", func(t *testing.T) {
+		d := decrypt.New(cr, NewStoreMock(), decrypt.WithRandomSource(randomMock{}))
+
+		if _, _, err := d.Start(context.Background(), "test/1"); err != nil {
+			t.Fatalf("start: %v", err)
+		}
+
+		if err := d.Clear(context.Background(), "test/1"); err != nil {
+			t.Fatalf("clear: %v", err)
+		}
+
+		_, _, err := d.Stop(context.Background(), "test/1", votes)
+		if !errors.Is(err, errorcode.NotExist) {
+			t.Errorf("stop returned `%v`, expected `%v`", err, errorcode.NotExist)
+		}
+	})
+
+	t.Run("start after clear", func(t *testing.T) {
+		d := decrypt.New(cr, NewStoreMock(), decrypt.WithRandomSource(randomMock{}))
+
+		if _, _, err := d.Start(context.Background(), "test/1"); err != nil {
+			t.Fatalf("start: %v", err)
+		}
+
+		if _, _, err := d.Stop(context.Background(), "test/1", votes); err != nil {
+			t.Fatalf("stop: %v", err)
+		}
+
+		if err := d.Clear(context.Background(), "test/1"); err != nil {
+			t.Fatalf("clear: %v", err)
+		}
+
+		if _, _, err := d.Start(context.Background(), "test/1"); err != nil {
+			t.Fatalf("start after clear: %v", err)
+		}
+
+		poll, err := d.GetPoll(context.Background(), "test/1")
+		if err != nil {
+			t.Fatalf("get poll: %v", err)
+		}
+
+		if poll.State != decrypt.StateStarted || !poll.Stopped.IsZero() {
+			t.Errorf("got poll %v, expected a new started poll", poll)
+		}
+	})
+
+	t.Run("unknown poll", func(t *testing.T) {
+		d := decrypt.New(cr, NewStoreMock())
+
+		_, err := d.GetPoll(context.Background(), "test/1")
+		if !errors.Is(err, errorcode.NotExist) {
+			t.Errorf("get poll returned `%v`, expected `%v`", err, errorcode.NotExist)
+		}
+	})
+}
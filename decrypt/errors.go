@@ -0,0 +1,41 @@
+package decrypt
+
+import "fmt"
+
+// RandomSourceError is returned by Stop, if the random source failed while
+// shuffling the votes.
+type RandomSourceError struct {
+	Err error
+}
+
+func (e RandomSourceError) Error() string {
+	return fmt.Sprintf("reading random source: %v", e.Err)
+}
+
+func (e RandomSourceError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorClass returns the class of the error used in logs.
+func (e RandomSourceError) ErrorClass() string {
+	return "random_source"
+}
+
+// CryptoError is returned by Stop, if the crypto backend failed for another
+// reason than an invalid vote.
+type CryptoError struct {
+	Err error
+}
+
+func (e CryptoError) Error() string {
+	return fmt.Sprintf("crypto backend: %v", e.Err)
+}
+
+func (e CryptoError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorClass returns the class of the error used in logs.
+func (e CryptoError) ErrorClass() string {
+	return "crypto"
+}
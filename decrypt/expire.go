@@ -0,0 +1,93 @@
+package decrypt
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/OpenSlides/vote-decrypt/logging"
+)
+
+// ExpiredPolls returns all polls from the store, that are expired at the given
+// time, but were not removed.
+func ExpiredPolls(store Store, now time.Time) ([]Poll, error) {
+	polls, err := store.ListPolls()
+	if err != nil {
+		return nil, fmt.Errorf("listing polls: %w", err)
+	}
+
+	var expired []Poll
+	for _, poll := range polls {
+		if poll.State != StateExpired && poll.IsExpired(now) {
+			expired = append(expired, poll)
+		}
+	}
+	return expired, nil
+}
+
+// ExpirePoll removes all data of the poll from the store and sets its state to
+// expired.
+//
+// It does not write the audit log. Use Decrypt.Expire for that.
+func ExpirePoll(store Store, id string) error {
+	if err := store.ClearPoll(id); err != nil {
+		return fmt.Errorf("clearing poll: %w", err)
+	}
+
+	if err := store.SetState(id, StateExpired); err != nil {
+		return fmt.Errorf("setting poll state: %w", err)
+	}
+
+	return nil
+}
+
+// RunJanitor removes expired polls every interval until the context is done.
+//
+// Does nothing, if interval is not positive.
+func (d *Decrypt) RunJanitor(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := d.expirePolls(ctx); err != nil {
+			d.logger.ErrorContext(ctx, "janitor failed", "error_class", logging.ErrorClass(err))
+		}
+	}
+}
+
+func (d *Decrypt) expirePolls(ctx context.Context) error {
+	polls, err := ExpiredPolls(d.store, time.Now())
+	if err != nil {
+		return fmt.Errorf("loading expired polls: %w", err)
+	}
+
+	for _, poll := range polls {
+		if err := d.Expire(ctx, poll.ID); err != nil {
+			return fmt.Errorf("expire poll %s: %w", poll.ID, err)
+		}
+		d.logger.InfoContext(ctx, "poll expired", "poll_id", poll.ID)
+	}
+
+	return nil
+}
+
+// Expire removes all data of the poll from the store and sets its state to
+// expired. The operation is written to the audit log first.
+//
+// It does not check, if the poll is expired. See ExpiredPolls.
+func (d *Decrypt) Expire(ctx context.Context, pollID string) error {
+	event := AuditEvent{Operation: AuditExpire, PollID: pollID}
+	return d.transition(ctx, StateExpired, event, func() error {
+		return ExpirePoll(d.store, pollID)
+	})
+}
@@ -0,0 +1,173 @@
+package decrypt_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/OpenSlides/vote-decrypt/decrypt"
+	"github.com/OpenSlides/vote-decrypt/errorcode"
+)
+
+func TestExpire(t *testing.T) {
+	cr := cryptoMock{}
+	votes := [][]byte{[]byte(`enc:"Y"`)}
+
+	t.Run("start sets expire time", func(t *testing.T) {
+		d := decrypt.New(cr, NewStoreMock(), decrypt.WithPollTTL(time.Hour))
+
+		if _, _, err := d.Start(context.Background(), "test/1"); err != nil {
+			t.Fatalf("start: %v", err)
+		}
+
+		poll, err := d.GetPoll(context.Background(), "test/1")
+		if err != nil {
+			t.Fatalf("get poll: %v", err)
+		}
+
+		if poll.Expires.Before(time.Now().Add(59*time.Minute)) || poll.Expires.After(time.Now().Add(time.Hour)) {
+			t.Errorf("got expire time %s, expected one hour from now", poll.Expires)
+		}
+	})
+
+	t.Run("no ttl", func(t *testing.T) {
+		d := decrypt.New(cr, NewStoreMock())
+
+		if _, _, err := d.Start(context.Background(), "test/1"); err != nil {
+			t.Fatalf("start: %v", err)
+		}
+
+		poll, err := d.GetPoll(context.Background(), "test/1")
+		if err != nil {
+			t.Fatalf("get poll: %v", err)
+		}
+
+		if !poll.Expires.IsZero() {
+			t.Errorf("got expire time %s, expected none", poll.Expires)
+		}
+	})
+
+	t.Run("stop expired poll", func(t *testing.T) {
+		d := decrypt.New(cr, NewStoreMock(), decrypt.WithRandomSource(randomMock{}))
+
+		if _, _, err := d.StartWithTTL(context.Background(), "test/1", time.Nanosecond); err != nil {
+			t.Fatalf("start: %v", err)
+		}
+		time.Sleep(time.Millisecond)
+
+		_, _, err := d.Stop(context.Background(), "test/1", votes)
+		if !errors.Is(err, errorcode.WrongState) {
+			t.Errorf("stop returned `%v`, expected `%v`", err, errorcode.WrongState)
+		}
+	})
+
+	t.Run("expire polls", func(t *testing.T) {
+		store := NewStoreMock()
+		d := decrypt.New(cr, store)
+
+		if _, _, err := d.StartWithTTL(context.Background(), "test/1", time.Nanosecond); err != nil {
+			t.Fatalf("start: %v", err)
+		}
+
+		if _, _, err := d.StartWithTTL(context.Background(), "test/2", time.Hour); err != nil {
+			t.Fatalf("start: %v", err)
+		}
+		time.Sleep(time.Millisecond)
+
+		expired, err := decrypt.ExpiredPolls(store, time.Now())
+		if err != nil {
+			t.Fatalf("expired polls: %v", err)
+		}
+
+		if len(expired) != 1 || expired[0].ID != "test/1" {
+			t.Fatalf("got expired polls %v, expected only test/1", expired)
+		}
+
+		if err := decrypt.ExpirePoll(store, "test/1"); err != nil {
+			t.Fatalf("expire poll: %v", err)
+		}
+
+		if _, err := store.LoadKey("test/1"); !errors.Is(err, errorcode.NotExist) {
+			t.Errorf("key of expired poll was not removed")
+		}
+
+		poll, err := d.GetPoll(context.Background(), "test/1")
+		if err != nil {
+			t.Fatalf("get poll: %v", err)
+		}
+
+		if poll.State != decrypt.StateExpired || poll.Expired.IsZero() {
+			t.Errorf("got poll %v, expected expired poll", poll)
+		}
+
+		expired, err = decrypt.ExpiredPolls(store, time.Now())
+		if err != nil {
+			t.Fatalf("expired polls: %v", err)
+		}
+
+		if len(expired) != 0 {
+			t.Errorf("got expired polls %v after they were removed", expired)
+		}
+	})
+
+	t.Run("expire with audit log", func(t *testing.T) {
+		auditLog := new(auditMock)
+		store := NewStoreMock()
+		d := decrypt.New(cr, store, decrypt.WithAuditLog(auditLog))
+
+		if _, _, err := d.StartWithTTL(context.Background(), "test/1", time.Nanosecond); err != nil {
+			t.Fatalf("start: %v", err)
+		}
+
+		if err := d.Expire(context.Background(), "test/1"); err != nil {
+			t.Fatalf("expire: %v", err)
+		}
+
+		if _, err := store.LoadKey("test/1"); !errors.Is(err, errorcode.NotExist) {
+			t.Errorf("key of expired poll was not removed")
+		}
+
+		last := auditLog.events[len(auditLog.events)-1]
+		if last.Operation != decrypt.AuditExpire || last.PollID != "test/1" {
+			t.Errorf("got last audit event %v, expected expire of test/1", last)
+		}
+	})
+
+	t.Run("janitor", func(t *testing.T) {
+		store := NewStoreMock()
+		d := decrypt.New(cr, store)
+
+		if _, _, err := d.StartWithTTL(context.Background(), "test/1", time.Nanosecond); err != nil {
+			t.Fatalf("start: %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			d.RunJanitor(ctx, time.Millisecond)
+			close(done)
+		}()
+
+		timeout := time.After(time.Second)
+		for {
+			poll, err := store.LoadPoll("test/1")
+			if err != nil {
+				t.Fatalf("load poll: %v", err)
+			}
+
+			if poll.State == decrypt.StateExpired {
+				break
+			}
+
+			select {
+			case <-timeout:
+				t.Fatalf("janitor did not expire the poll")
+			case <-time.After(time.Millisecond):
+			}
+		}
+
+		cancel()
+		<-done
+	})
+}
@@ -0,0 +1,120 @@
+package decrypt_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/OpenSlides/vote-decrypt/decrypt"
+	"go.uber.org/goleak"
+)
+
+// failingReader returns an error after n bytes.
+type failingReader struct {
+	n   int
+	err error
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.n <= 0 {
+		return 0, r.err
+	}
+
+	if len(p) > r.n {
+		p = p[:r.n]
+	}
+	for i := range p {
+		p[i] = 0
+	}
+	r.n -= len(p)
+	return len(p), nil
+}
+
+// failingCrypto returns an error from Decrypt, that is not an invalid vote.
+type failingCrypto struct {
+	cryptoMock
+	err error
+}
+
+func (c failingCrypto) NewDecrypter(key []byte) (decrypt.Decrypter, error) {
+	return decrypterFunc(func(value []byte) ([]byte, error) {
+		return nil, c.err
+	}), nil
+}
+
+func TestStopRandomSourceError(t *testing.T) {
+	errRandom := errors.New("random source broken")
+
+	for _, tt := range []struct {
+		name  string
+		bytes int
+	}{
+		{"immediately", 0},
+		{"after some votes", 20},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+			d := decrypt.New(
+				cryptoMock{},
+				NewStoreMock(),
+				decrypt.WithRandomSource(&failingReader{n: tt.bytes, err: errRandom}),
+			)
+
+			if _, _, err := d.Start(context.Background(), "test/1"); err != nil {
+				t.Fatalf("start: %v", err)
+			}
+
+			_, _, err := d.Stop(context.Background(), "test/1", manyVotes(100))
+
+			var randErr decrypt.RandomSourceError
+			if !errors.As(err, &randErr) {
+				t.Fatalf("stop returned `%v`, expected a RandomSourceError", err)
+			}
+
+			if !errors.Is(err, errRandom) {
+				t.Errorf("stop returned `%v`, expected to wrap `%v`", err, errRandom)
+			}
+
+			poll, err := d.GetPoll(context.Background(), "test/1")
+			if err != nil {
+				t.Fatalf("get poll: %v", err)
+			}
+
+			if poll.State != decrypt.StateStarted {
+				t.Errorf("got state %s, expected %s", poll.State, decrypt.StateStarted)
+			}
+		})
+	}
+}
+
+func TestStopCryptoError(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	errBackend := errors.New("backend broken")
+	d := decrypt.New(failingCrypto{err: errBackend}, NewStoreMock())
+
+	if _, _, err := d.Start(context.Background(), "test/1"); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	_, _, err := d.Stop(context.Background(), "test/1", manyVotes(100))
+
+	var cryptoErr decrypt.CryptoError
+	if !errors.As(err, &cryptoErr) {
+		t.Fatalf("stop returned `%v`, expected a CryptoError", err)
+	}
+
+	if !errors.Is(err, errBackend) {
+		t.Errorf("stop returned `%v`, expected to wrap `%v`", err, errBackend)
+	}
+
+	poll, err := d.GetPoll(context.Background(), "test/1")
+	if err != nil {
+		t.Fatalf("get poll: %v", err)
+	}
+
+	if poll.State != decrypt.StateStarted {
+		t.Errorf("got state %s, expected %s", poll.State, decrypt.StateStarted)
+	}
+}
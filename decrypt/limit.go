@@ -0,0 +1,51 @@
+package decrypt
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/OpenSlides/vote-decrypt/errorcode"
+)
+
+// checkLimits makes sure, that the votes are in the configured limits.
+func (d *Decrypt) checkLimits(voteList [][]byte) error {
+	if len(voteList) > d.maxVotes {
+		return fmt.Errorf("received %d votes, only %d votes supported: %w", len(voteList), d.maxVotes, errorcode.Exhausted)
+	}
+
+	if d.maxVoteSize > 0 {
+		for _, vote := range voteList {
+			if len(vote) > d.maxVoteSize {
+				return fmt.Errorf("received a vote with %d bytes, only %d bytes supported: %w", len(vote), d.maxVoteSize, errorcode.Exhausted)
+			}
+		}
+	}
+
+	return nil
+}
+
+// acquireStop reserves a slot for a call to Stop. The returned function has to
+// be called to release the slot.
+//
+// Returns errorcode.Exhausted, if all slots are used.
+func (d *Decrypt) acquireStop() (func(), error) {
+	if d.stopSlots == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case d.stopSlots <- struct{}{}:
+		return func() { <-d.stopSlots }, nil
+	default:
+		return nil, fmt.Errorf("too many concurrent calls to stop: %w", errorcode.Exhausted)
+	}
+}
+
+// stopTimeout returns the timeout for a call to Stop with the given number of
+// votes. 0 means no timeout.
+func (d *Decrypt) stopTimeout(votes int) time.Duration {
+	if d.stopTimeoutBase == 0 && d.stopTimeoutPerVote == 0 {
+		return 0
+	}
+	return d.stopTimeoutBase + time.Duration(votes)*d.stopTimeoutPerVote
+}
@@ -0,0 +1,96 @@
+package decrypt_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/OpenSlides/vote-decrypt/decrypt"
+	"github.com/OpenSlides/vote-decrypt/errorcode"
+)
+
+// blockingCrypto blocks in Decrypt until unblock is closed.
+type blockingCrypto struct {
+	cryptoMock
+	started chan struct{}
+	unblock chan struct{}
+}
+
+func (c blockingCrypto) NewDecrypter(key []byte) (decrypt.Decrypter, error) {
+	return decrypterFunc(func(value []byte) ([]byte, error) {
+		c.started <- struct{}{}
+		<-c.unblock
+		return mockDecrypt(value)
+	}), nil
+}
+
+func TestMaxVoteSize(t *testing.T) {
+	ctx := context.Background()
+	d := decrypt.New(
+		cryptoMock{},
+		NewStoreMock(),
+		decrypt.WithRandomSource(randomMock{}),
+		decrypt.WithMaxVoteSize(8),
+	)
+
+	if _, _, err := d.Start(ctx, "test/1"); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	_, _, err := d.Stop(ctx, "test/1", [][]byte{[]byte(`enc:"Y"`), []byte(`enc:"too big"`)})
+	if !errors.Is(err, errorcode.Exhausted) {
+		t.Errorf("stop returned `%v` expected `%v`", err, errorcode.Exhausted)
+	}
+
+	if _, _, err := d.Stop(ctx, "test/1", [][]byte{[]byte(`enc:"Y"`)}); err != nil {
+		t.Errorf("stop with small votes: %v", err)
+	}
+}
+
+func TestMaxConcurrentStops(t *testing.T) {
+	ctx := context.Background()
+	cr := blockingCrypto{
+		started: make(chan struct{}),
+		unblock: make(chan struct{}),
+	}
+
+	d := decrypt.New(
+		cr,
+		NewStoreMock(),
+		decrypt.WithRandomSource(randomMock{}),
+		decrypt.WithMaxConcurrentStops(1),
+	)
+
+	for _, id := range []string{"test/1", "test/2"} {
+		if _, _, err := d.Start(ctx, id); err != nil {
+			t.Fatalf("start: %v", err)
+		}
+	}
+
+	done := make(chan error)
+	go func() {
+		_, _, err := d.Stop(ctx, "test/1", [][]byte{[]byte(`enc:"Y"`)})
+		done <- err
+	}()
+	<-cr.started
+
+	_, _, err := d.Stop(ctx, "test/2", [][]byte{[]byte(`enc:"Y"`)})
+	if !errors.Is(err, errorcode.Exhausted) {
+		t.Errorf("second stop returned `%v` expected `%v`", err, errorcode.Exhausted)
+	}
+
+	close(cr.unblock)
+	if err := <-done; err != nil {
+		t.Fatalf("first stop: %v", err)
+	}
+
+	// After the first call is done, the slot is free again.
+	go func() {
+		for range cr.started {
+		}
+	}()
+	if _, _, err := d.Stop(ctx, "test/2", [][]byte{[]byte(`enc:"Y"`)}); err != nil {
+		t.Errorf("stop after the first call: %v", err)
+	}
+	close(cr.started)
+}
@@ -0,0 +1,108 @@
+package decrypt_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/OpenSlides/vote-decrypt/crypto"
+	"github.com/OpenSlides/vote-decrypt/decrypt"
+	"github.com/OpenSlides/vote-decrypt/logging"
+	"github.com/OpenSlides/vote-decrypt/store"
+)
+
+func TestLoggingNoSecrets(t *testing.T) {
+	for _, format := range []string{"text", "json"} {
+		t.Run(format, func(t *testing.T) {
+			ctx := context.Background()
+			buf := new(bytes.Buffer)
+			logger, err := logging.New(buf, format, "debug")
+			if err != nil {
+				t.Fatalf("creating logger: %v", err)
+			}
+
+			mainKey := make([]byte, 32)
+			if _, err := rand.Read(mainKey); err != nil {
+				t.Fatalf("creating main key: %v", err)
+			}
+
+			st := store.New(t.TempDir())
+			d := decrypt.New(crypto.New(mainKey, rand.Reader, nil), st, decrypt.WithLogger(logger))
+
+			pubKey, _, err := d.Start(ctx, "test/1")
+			if err != nil {
+				t.Fatalf("start: %v", err)
+			}
+
+			pollKey, err := st.LoadKey("test/1")
+			if err != nil {
+				t.Fatalf("loading poll key: %v", err)
+			}
+
+			secrets := [][]byte{mainKey, pollKey}
+			var votes [][]byte
+			for _, plaintext := range []string{`"secret-plaintext-one"`, `"secret-plaintext-two"`} {
+				ciphertext, err := crypto.Encrypt(rand.Reader, ecdh.X25519(), pubKey, []byte(plaintext))
+				if err != nil {
+					t.Fatalf("encrypt: %v", err)
+				}
+				votes = append(votes, ciphertext)
+				secrets = append(secrets, []byte(plaintext), ciphertext)
+			}
+
+			invalidVote := []byte("secret-invalid-ciphertext")
+			votes = append(votes, invalidVote)
+			secrets = append(secrets, invalidVote)
+
+			if _, _, err := d.Stop(ctx, "test/1", votes); err != nil {
+				t.Fatalf("stop: %v", err)
+			}
+
+			if _, _, err := d.Stop(ctx, "test/unknown", votes); err == nil {
+				t.Fatalf("stop on unknown poll did not return an error")
+			}
+
+			if err := d.Clear(ctx, "test/1"); err != nil {
+				t.Fatalf("clear: %v", err)
+			}
+
+			out := buf.String()
+			if !strings.Contains(out, "test/1") {
+				t.Errorf("log does not contain the poll id:\n%s", out)
+			}
+
+			if !strings.Contains(out, "failed_votes") {
+				t.Errorf("log does not contain the failed votes:\n%s", out)
+			}
+
+			assertNoSecrets(t, out, secrets...)
+		})
+	}
+}
+
+// assertNoSecrets fails, if the log output contains one of the secrets in any
+// common encoding.
+func assertNoSecrets(t *testing.T, out string, secrets ...[]byte) {
+	t.Helper()
+
+	for _, secret := range secrets {
+		encodings := []string{
+			string(secret),
+			base64.StdEncoding.EncodeToString(secret),
+			base64.RawStdEncoding.EncodeToString(secret),
+			base64.URLEncoding.EncodeToString(secret),
+			hex.EncodeToString(secret),
+		}
+
+		for _, encoded := range encodings {
+			if strings.Contains(out, encoded) {
+				t.Errorf("log contains secret %q:\n%s", encoded, out)
+			}
+		}
+	}
+}
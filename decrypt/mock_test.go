@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"fmt"
 	"sync"
+	"time"
 
+	"github.com/OpenSlides/vote-decrypt/decrypt"
 	"github.com/OpenSlides/vote-decrypt/errorcode"
 )
 
@@ -25,16 +27,28 @@ func (c cryptoMock) PublicPollKey(key []byte) (pubKey []byte, pubKeySig []byte,
 	return []byte("pollPubKey"), []byte("pollKeySig"), nil
 }
 
-// Decrypt returned the plaintext from value using the key.
-func (c cryptoMock) Decrypt(key []byte, value []byte) ([]byte, error) {
+// NewDecrypter returns a decrypter, that removes the prefix `enc:`.
+func (c cryptoMock) NewDecrypter(key []byte) (decrypt.Decrypter, error) {
+	return decrypterFunc(mockDecrypt), nil
+}
+
+// mockDecrypt returns the value without the prefix `enc:`.
+func mockDecrypt(value []byte) ([]byte, error) {
 	prefix := []byte("enc:")
 
 	if !bytes.HasPrefix(value, prefix) {
-		return nil, fmt.Errorf("decrypt error")
+		return nil, fmt.Errorf("decrypt error: %w", errorcode.Invalid)
 	}
 	return bytes.TrimPrefix(value, prefix), nil
 }
 
+// decrypterFunc implements decrypt.Decrypter with a function.
+type decrypterFunc func(value []byte) ([]byte, error)
+
+func (f decrypterFunc) Decrypt(value []byte) ([]byte, error) {
+	return f(value)
+}
+
 // Returns the signature for the given data.
 func (c cryptoMock) Sign(value []byte) []byte {
 	return []byte(fmt.Sprintf("sig:%s", value))
@@ -44,12 +58,14 @@ type StoreMock struct {
 	mu         sync.Mutex
 	keys       map[string][]byte
 	signatures map[string][]byte
+	polls      map[string]decrypt.Poll
 }
 
 func NewStoreMock() *StoreMock {
 	return &StoreMock{
 		keys:       make(map[string][]byte),
 		signatures: make(map[string][]byte),
+		polls:      make(map[string]decrypt.Poll),
 	}
 }
 
@@ -62,9 +78,29 @@ func (s *StoreMock) SaveKey(id string, key []byte) error {
 	}
 
 	s.keys[id] = key
+	s.polls[id] = decrypt.Poll{ID: id, State: decrypt.StateCreated, Created: time.Now()}
 	return nil
 }
 
+// LoadOrCreateKey returns the private key or creates a new one.
+func (s *StoreMock) LoadOrCreateKey(id string, create func() ([]byte, error)) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key := s.keys[id]; key != nil {
+		return key, false, nil
+	}
+
+	key, err := create()
+	if err != nil {
+		return nil, false, err
+	}
+
+	s.keys[id] = key
+	s.polls[id] = decrypt.Poll{ID: id, State: decrypt.StateCreated, Created: time.Now()}
+	return key, true, nil
+}
+
 // LoadKey returns the private key from the store.
 //
 // If the poll is unknown return (nil, nil)
@@ -87,6 +123,12 @@ func (s *StoreMock) ValidateSignature(id string, signature []byte) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	return s.validateSignature(id, signature)
+}
+
+// validateSignature is like ValidateSignature but expects, that the lock is
+// held.
+func (s *StoreMock) validateSignature(id string, signature []byte) error {
 	if s.keys[id] == nil {
 		return errorcode.NotExist
 	}
@@ -112,9 +154,75 @@ func (s *StoreMock) ClearPoll(id string) error {
 
 	delete(s.keys, id)
 	delete(s.signatures, id)
+
+	poll, ok := s.polls[id]
+	if !ok {
+		return nil
+	}
+	poll.State = decrypt.StateCleared
+	poll.SetTime(decrypt.StateCleared, time.Now())
+	s.polls[id] = poll
+	return nil
+}
+
+// LoadPoll returns the meta data of a poll.
+func (s *StoreMock) LoadPoll(id string) (decrypt.Poll, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	poll, ok := s.polls[id]
+	if !ok {
+		return decrypt.Poll{}, errorcode.NotExist
+	}
+	return poll, nil
+}
+
+// SetState sets the state of a poll.
+func (s *StoreMock) SetState(id string, state decrypt.PollState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	poll, ok := s.polls[id]
+	if !ok {
+		return errorcode.NotExist
+	}
+
+	if state == decrypt.StateStarted && !poll.State.CanStart() {
+		return errorcode.WrongState
+	}
+
+	poll.State = state
+	poll.SetTime(state, time.Now())
+	s.polls[id] = poll
+	return nil
+}
+
+// SetExpires sets the time, when the poll expires.
+func (s *StoreMock) SetExpires(id string, expires time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	poll, ok := s.polls[id]
+	if !ok {
+		return errorcode.NotExist
+	}
+	poll.Expires = expires
+	s.polls[id] = poll
 	return nil
 }
 
+// ListPolls returns all polls.
+func (s *StoreMock) ListPolls() ([]decrypt.Poll, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	polls := make([]decrypt.Poll, 0, len(s.polls))
+	for _, poll := range s.polls {
+		polls = append(polls, poll)
+	}
+	return polls, nil
+}
+
 type randomMock struct{}
 
 func (r randomMock) Read(data []byte) (n int, err error) {
@@ -123,3 +231,15 @@ func (r randomMock) Read(data []byte) (n int, err error) {
 	}
 	return len(data), nil
 }
+
+type auditMock struct {
+	mu     sync.Mutex
+	events []decrypt.AuditEvent
+}
+
+func (a *auditMock) Record(event decrypt.AuditEvent) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.events = append(a.events, event)
+	return nil
+}
@@ -1,6 +1,12 @@
 package decrypt
 
-import "io"
+import (
+	"io"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
 
 // Option for decrypt.New().
 type Option = func(*Decrypt)
@@ -17,13 +23,63 @@ func WithRandomSource(r io.Reader) Option {
 	}
 }
 
+// WithDecryptWorkers sets the number of votes, that are decrypted at the same
+// time. The workers are shared by all polls. Uses GOMAXPROCS as default.
+//
+// Values smaller than 1 are ignored.
+func WithDecryptWorkers(workers int) Option {
+	return func(d *Decrypt) {
+		if workers > 0 {
+			d.decryptWorkers = workers
+		}
+	}
+}
+
 // WithMaxVotes sets the number of maximum votes, that are supported.
+//
+// Stop returns errorcode.Exhausted for polls with more votes.
 func WithMaxVotes(maxVotes int) Option {
 	return func(d *Decrypt) {
 		d.maxVotes = maxVotes
 	}
 }
 
+// WithMaxVoteSize sets the maximum size of one encrypted vote in bytes. 0
+// means no limit, which is the default.
+//
+// Stop returns errorcode.Exhausted, if one vote is bigger.
+func WithMaxVoteSize(size int) Option {
+	return func(d *Decrypt) {
+		d.maxVoteSize = size
+	}
+}
+
+// WithStopTimeout sets a timeout for Stop, that depends on the size of the
+// poll. The timeout is base + perVote * number of votes. If both values are 0,
+// there is no timeout, which is the default.
+//
+// When the timeout is reached, the decryption is aborted and Stop returns an
+// error that wraps context.DeadlineExceeded.
+func WithStopTimeout(base time.Duration, perVote time.Duration) Option {
+	return func(d *Decrypt) {
+		d.stopTimeoutBase = base
+		d.stopTimeoutPerVote = perVote
+	}
+}
+
+// WithMaxConcurrentStops sets the number of calls to Stop, that can run at the
+// same time. 0 means no limit, which is the default.
+//
+// Additional calls return errorcode.Exhausted.
+func WithMaxConcurrentStops(n int) Option {
+	return func(d *Decrypt) {
+		d.stopSlots = nil
+		if n > 0 {
+			d.stopSlots = make(chan struct{}, n)
+		}
+	}
+}
+
 // WithListToContent takes a function that is used to create the content
 // returned from the Stop() call.
 //
@@ -34,3 +90,68 @@ func WithListToContent(f func(id string, decrypted [][]byte) ([]byte, error)) Op
 		d.listToContent = f
 	}
 }
+
+// WithPollTTL sets the default time to live for polls. After this time, a poll
+// expires and its key can be removed with RunJanitor().
+//
+// The default is 0, which means, that polls do not expire.
+func WithPollTTL(ttl time.Duration) Option {
+	return func(d *Decrypt) {
+		d.pollTTL = ttl
+	}
+}
+
+// WithTenantCrypto sets the crypto backend for a tenant. It is used for all
+// polls, which id starts with the tenant name followed by a slash, and for
+// PublicMainKey() with this tenant. See WithTenant().
+//
+// Can be used more then once for different tenants. Tenants without an own
+// crypto backend use the crypto backend from decrypt.New().
+func WithTenantCrypto(tenant string, crypto Crypto) Option {
+	return func(d *Decrypt) {
+		if d.tenants == nil {
+			d.tenants = make(map[string]Crypto)
+		}
+		d.tenants[tenant] = crypto
+	}
+}
+
+// WithTracerProvider sets the provider for the tracer, that creates the spans
+// in Start() and Stop(). Uses the global provider as default.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(d *Decrypt) {
+		d.tracer = provider.Tracer(tracerName)
+	}
+}
+
+// WithLogger sets the logger. Uses slog.Default() as default.
+//
+// The decrypt component only logs poll ids, counts and error classes. It never
+// logs keys or votes.
+func WithLogger(logger *slog.Logger) Option {
+	return func(d *Decrypt) {
+		d.logger = logger
+	}
+}
+
+// WithAuditLog writes all operations that change the state of a poll to the
+// audit log.
+//
+// Can be used more then once to write to different logs.
+func WithAuditLog(log AuditLog) Option {
+	return func(d *Decrypt) {
+		d.auditLogs = append(d.auditLogs, log)
+	}
+}
+
+// WithCommitReveal enables the commit-reveal mode for the order of the votes.
+//
+// In this mode, each started poll has a signed commitment to a seed (see
+// SeedCommitment()). StopWithContribution() orders the decrypted votes with
+// the seed and a public contribution and reveals the seed. So anyone can check,
+// that the order was not chosen by the server.
+func WithCommitReveal() Option {
+	return func(d *Decrypt) {
+		d.commitReveal = true
+	}
+}
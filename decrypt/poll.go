@@ -0,0 +1,112 @@
+package decrypt
+
+import (
+	"fmt"
+	"time"
+)
+
+// PollState is the lifecycle state of a poll.
+//
+// A poll is created, when its key is saved in the store. It is started, when
+// the public key was returned by Start() and stopped after the first
+// successfull call to Stop(). Clear() moves a poll from any state to cleared.
+// An abandoned poll is expired.
+type PollState int
+
+// All states of a poll.
+const (
+	StateUnknown PollState = iota
+	StateCreated
+	StateStarted
+	StateStopped
+	StateCleared
+	StateExpired
+)
+
+var pollStateNames = map[PollState]string{
+	StateUnknown: "unknown",
+	StateCreated: "created",
+	StateStarted: "started",
+	StateStopped: "stopped",
+	StateCleared: "cleared",
+	StateExpired: "expired",
+}
+
+func (s PollState) String() string {
+	name, ok := pollStateNames[s]
+	if !ok {
+		return pollStateNames[StateUnknown]
+	}
+	return name
+}
+
+// CanStart returns true, if a poll in this state can be set to StateStarted.
+// Only created and started polls can be started.
+func (s PollState) CanStart() bool {
+	return s == StateCreated || s == StateStarted
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s PollState) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (s *PollState) UnmarshalText(text []byte) error {
+	for state, name := range pollStateNames {
+		if name == string(text) {
+			*s = state
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown poll state %q", text)
+}
+
+// Poll holds the meta data of a poll.
+//
+// The timestamps are set by the store, when the poll reaches the state. A zero
+// value means, that the poll never had this state.
+//
+// Expires is the time, after which the poll is treated as abandoned. A zero
+// value means, that the poll does not expire.
+type Poll struct {
+	ID      string
+	State   PollState
+	Expires time.Time
+
+	Created time.Time
+	Started time.Time
+	Stopped time.Time
+	Cleared time.Time
+	Expired time.Time
+}
+
+// SetTime sets the timestamp that belongs to the given state.
+//
+// Helper for store implementations.
+func (p *Poll) SetTime(state PollState, t time.Time) {
+	switch state {
+	case StateCreated:
+		p.Created = t
+	case StateStarted:
+		p.Started = t
+	case StateStopped:
+		p.Stopped = t
+	case StateCleared:
+		p.Cleared = t
+	case StateExpired:
+		p.Expired = t
+	}
+}
+
+// IsExpired returns true, if the poll is expired or it should be expired at
+// the given time. A cleared poll is never expired.
+func (p Poll) IsExpired(now time.Time) bool {
+	switch p.State {
+	case StateExpired:
+		return true
+	case StateCleared:
+		return false
+	}
+	return !p.Expires.IsZero() && now.After(p.Expires)
+}
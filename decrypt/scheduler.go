@@ -0,0 +1,234 @@
+package decrypt
+
+import (
+	"context"
+	"sync"
+
+	"github.com/OpenSlides/vote-decrypt/metrics"
+)
+
+// chunkSize is the number of votes, a worker decrypts before the scheduler
+// chooses the next poll.
+const chunkSize = 64
+
+// Range of the priority of a Stop call. See WithPriority().
+const (
+	MinPriority = -3
+	MaxPriority = 3
+)
+
+// WithPriority returns a context for Stop calls with the given priority.
+//
+// The priority is clamped to MinPriority and MaxPriority. The scheduler gives
+// each poll a share of the workers, that doubles with each priority level. So
+// polls with a higher priority are decrypted faster, but polls with a lower
+// priority still make progress. The default priority is 0.
+func WithPriority(ctx context.Context, priority int) context.Context {
+	return context.WithValue(ctx, priorityKey, min(max(priority, MinPriority), MaxPriority))
+}
+
+// PriorityFromContext returns the priority from the context.
+func PriorityFromContext(ctx context.Context) int {
+	priority, _ := ctx.Value(priorityKey).(int)
+	return priority
+}
+
+// QueueStats is the state of the decrypt scheduler.
+type QueueStats struct {
+	// Workers is the number of votes, that can be decrypted at the same time.
+	Workers int
+
+	// Busy is the number of workers, that are decrypting votes.
+	Busy int
+
+	// Polls is the number of polls with votes, that wait for a worker.
+	Polls int
+
+	// Votes is the number of votes, that wait for a worker.
+	Votes int
+}
+
+// QueueStats returns the state of the decrypt scheduler.
+func (d *Decrypt) QueueStats() QueueStats {
+	return d.scheduler.stats()
+}
+
+// scheduler distributes the decryption of votes from all Stop calls to a
+// bounded number of workers.
+//
+// The votes of a poll are split into chunks. The polls are served with stride
+// scheduling: Each job has a pass value, that grows by its stride, when a
+// chunk is taken. A free worker takes the next chunk from the job with the
+// smallest pass. The stride halves with each priority level, so a job with a
+// higher priority gets more chunks. A new job starts at the pass of the last
+// chunk. So it can not starve the jobs, that already wait, and it is not
+// starved by them.
+type scheduler struct {
+	workers int
+
+	mu    sync.Mutex
+	busy  int
+	votes int
+	pass  uint64 // pass of the last chunk.
+	jobs  []*job // queued jobs in the order they were added.
+}
+
+// job is the decryption of one poll.
+type job struct {
+	stride  uint64
+	pass    uint64
+	total   int
+	next    int
+	running int
+	queued  bool
+	done    chan struct{}
+	run     func(from, to int)
+}
+
+// stride returns the stride for a priority. It is 1 for MaxPriority and
+// doubles for each lower level.
+func stride(priority int) uint64 {
+	return 1 << (MaxPriority - min(max(priority, MinPriority), MaxPriority))
+}
+
+func newScheduler(workers int) *scheduler {
+	return &scheduler{
+		workers: max(workers, 1),
+	}
+}
+
+// run calls f for all ranges of the numbers 0 to n-1 and returns when all
+// calls are finished.
+//
+// If the context is canceled, no new ranges are started. f has to check the
+// context itself. run returns after all running calls to f have returned.
+func (s *scheduler) run(ctx context.Context, priority int, n int, f func(from, to int)) {
+	if n == 0 {
+		return
+	}
+
+	j := &job{
+		stride: stride(priority),
+		total:  n,
+		done:   make(chan struct{}),
+		run:    f,
+	}
+
+	s.mu.Lock()
+	j.pass = s.pass
+	s.enqueueLocked(j)
+	s.dispatchLocked()
+	s.mu.Unlock()
+
+	select {
+	case <-j.done:
+		return
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	if j.queued {
+		s.removeLocked(j)
+		s.finishLocked(j)
+	}
+	s.mu.Unlock()
+
+	<-j.done
+}
+
+func (s *scheduler) stats() QueueStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return QueueStats{
+		Workers: s.workers,
+		Busy:    s.busy,
+		Polls:   len(s.jobs),
+		Votes:   s.votes,
+	}
+}
+
+func (s *scheduler) enqueueLocked(j *job) {
+	s.jobs = append(s.jobs, j)
+	j.queued = true
+	s.votes += j.total - j.next
+	s.updateMetricsLocked()
+}
+
+func (s *scheduler) removeLocked(j *job) {
+	for i, other := range s.jobs {
+		if other == j {
+			s.jobs = append(s.jobs[:i], s.jobs[i+1:]...)
+			break
+		}
+	}
+
+	j.queued = false
+	s.votes -= j.total - j.next
+	s.updateMetricsLocked()
+}
+
+// nextLocked returns the job with the smallest pass. If more jobs have the
+// same pass, the first one is returned.
+func (s *scheduler) nextLocked() *job {
+	var next *job
+	for _, j := range s.jobs {
+		if next == nil || j.pass < next.pass {
+			next = j
+		}
+	}
+	return next
+}
+
+// dispatchLocked starts chunks until all workers are busy.
+func (s *scheduler) dispatchLocked() {
+	for s.busy < s.workers {
+		j := s.nextLocked()
+		if j == nil {
+			return
+		}
+
+		// Move the job to the end of the queue, so jobs with the same pass
+		// are served round robin.
+		s.removeLocked(j)
+		from := j.next
+		to := min(from+chunkSize, j.total)
+		j.next = to
+		s.pass = j.pass
+		j.pass += j.stride
+		if j.next < j.total {
+			s.enqueueLocked(j)
+		}
+
+		j.running++
+		s.busy++
+		s.updateMetricsLocked()
+		go s.runChunk(j, from, to)
+	}
+}
+
+func (s *scheduler) runChunk(j *job, from, to int) {
+	j.run(from, to)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j.running--
+	s.busy--
+	s.finishLocked(j)
+	s.dispatchLocked()
+	s.updateMetricsLocked()
+}
+
+// finishLocked closes the done channel of the job, if no chunk is running
+// and no chunk is queued.
+func (s *scheduler) finishLocked(j *job) {
+	if j.running == 0 && !j.queued {
+		close(j.done)
+	}
+}
+
+func (s *scheduler) updateMetricsLocked() {
+	metrics.DecryptWorkersBusy.Set(float64(s.busy))
+	metrics.DecryptQueuedVotes.Set(float64(s.votes))
+}
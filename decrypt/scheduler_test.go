@@ -0,0 +1,179 @@
+package decrypt_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OpenSlides/vote-decrypt/decrypt"
+	"go.uber.org/goleak"
+)
+
+// stopAsync calls Stop in the background and returns a channel, that gets the
+// error.
+func stopAsync(ctx context.Context, d *decrypt.Decrypt, pollID string, votes int) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := d.Stop(ctx, pollID, manyVotes(votes))
+		done <- err
+	}()
+	return done
+}
+
+func startPolls(t *testing.T, d *decrypt.Decrypt, pollIDs ...string) {
+	t.Helper()
+
+	for _, pollID := range pollIDs {
+		if _, _, err := d.Start(context.Background(), pollID); err != nil {
+			t.Fatalf("start %s: %v", pollID, err)
+		}
+	}
+}
+
+func TestSchedulerFairness(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	cr := slowCrypto{delay: time.Millisecond, started: make(chan struct{}, 1)}
+	d := decrypt.New(cr, NewStoreMock(), decrypt.WithDecryptWorkers(1))
+	startPolls(t, d, "test/big", "test/small")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	bigDone := stopAsync(ctx, d, "test/big", 2_000)
+	<-cr.started
+
+	if err := <-stopAsync(context.Background(), d, "test/small", 10); err != nil {
+		t.Fatalf("stop small poll: %v", err)
+	}
+
+	select {
+	case <-bigDone:
+		t.Errorf("big poll finished before the small poll")
+	default:
+	}
+
+	cancel()
+	<-bigDone
+}
+
+func TestSchedulerPriority(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	cr := slowCrypto{delay: time.Millisecond, started: make(chan struct{}, 1)}
+	d := decrypt.New(cr, NewStoreMock(), decrypt.WithDecryptWorkers(1))
+	startPolls(t, d, "test/high", "test/low")
+
+	lowDone := stopAsync(context.Background(), d, "test/low", 300)
+	<-cr.started
+
+	highDone := stopAsync(decrypt.WithPriority(context.Background(), decrypt.MaxPriority), d, "test/high", 300)
+
+	select {
+	case err := <-highDone:
+		if err != nil {
+			t.Fatalf("stop high priority poll: %v", err)
+		}
+	case <-lowDone:
+		t.Fatalf("low priority poll finished first")
+	}
+
+	if err := <-lowDone; err != nil {
+		t.Fatalf("stop low priority poll: %v", err)
+	}
+}
+
+func TestSchedulerNoStarvation(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	cr := slowCrypto{delay: time.Millisecond, started: make(chan struct{}, 1)}
+	d := decrypt.New(cr, NewStoreMock(), decrypt.WithDecryptWorkers(1))
+	startPolls(t, d, "test/low")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	high := decrypt.WithPriority(ctx, decrypt.MaxPriority)
+
+	// Keep two high priority polls in the queue until the test ends.
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for n := 0; ctx.Err() == nil; n++ {
+				pollID := fmt.Sprintf("test/high.%d.%d", i, n)
+				if _, _, err := d.Start(ctx, pollID); err != nil {
+					if ctx.Err() == nil {
+						t.Errorf("start %s: %v", pollID, err)
+					}
+					return
+				}
+				d.Stop(high, pollID, manyVotes(256))
+			}
+		}(i)
+	}
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+	<-cr.started
+
+	// The poll has three chunks. The first one is decrypted at once. The
+	// others have to wait for chunks of the high priority polls.
+	lowDone := stopAsync(context.Background(), d, "test/low", 3*64)
+
+	select {
+	case err := <-lowDone:
+		if err != nil {
+			t.Fatalf("stop low priority poll: %v", err)
+		}
+	case <-time.After(20 * time.Second):
+		t.Fatalf("low priority poll did not finish while high priority polls arrived")
+	}
+}
+
+func TestWithPriority(t *testing.T) {
+	for _, tt := range []struct {
+		priority int
+		expect   int
+	}{
+		{0, 0},
+		{decrypt.MaxPriority, decrypt.MaxPriority},
+		{1_000, decrypt.MaxPriority},
+		{-1_000, decrypt.MinPriority},
+	} {
+		ctx := decrypt.WithPriority(context.Background(), tt.priority)
+		if got := decrypt.PriorityFromContext(ctx); got != tt.expect {
+			t.Errorf("WithPriority(%d) has priority %d, expected %d", tt.priority, got, tt.expect)
+		}
+	}
+}
+
+func TestQueueStats(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	cr := slowCrypto{delay: time.Millisecond, started: make(chan struct{}, 1)}
+	d := decrypt.New(cr, NewStoreMock(), decrypt.WithDecryptWorkers(2))
+	startPolls(t, d, "test/1")
+
+	if got := d.QueueStats(); got != (decrypt.QueueStats{Workers: 2}) {
+		t.Errorf("got stats %+v before stop, expected an empty queue", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := stopAsync(ctx, d, "test/1", 1_000)
+	<-cr.started
+
+	got := d.QueueStats()
+	if got.Busy != 2 || got.Polls != 1 || got.Votes == 0 {
+		t.Errorf("got stats %+v while decrypting, expected busy workers and queued votes", got)
+	}
+
+	cancel()
+	<-done
+
+	if got := d.QueueStats(); got != (decrypt.QueueStats{Workers: 2}) {
+		t.Errorf("got stats %+v after stop, expected an empty queue", got)
+	}
+}
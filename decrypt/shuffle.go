@@ -0,0 +1,86 @@
+package decrypt
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/bits"
+)
+
+// shuffle returns a random permutation of the votes.
+//
+// The given slice is not modified. See permutation().
+func shuffle(source io.Reader, votes [][]byte) ([][]byte, error) {
+	perm, err := permutation(source, len(votes))
+	if err != nil {
+		return nil, err
+	}
+
+	shuffled := make([][]byte, len(votes))
+	for i, j := range perm {
+		shuffled[i] = votes[j]
+	}
+	return shuffled, nil
+}
+
+// permutation returns a random permutation of the numbers 0 to n-1.
+//
+// It uses the Fisher–Yates shuffle, so each permutation has the same
+// probability, as long as the source is uniform.
+func permutation(source io.Reader, n int) ([]int, error) {
+	perm := make([]int, n)
+	for i := range perm {
+		perm[i] = i
+	}
+
+	r := intReader{source: source}
+	for i := n - 1; i > 0; i-- {
+		j, err := r.intn(i + 1)
+		if err != nil {
+			return nil, fmt.Errorf("shuffle position %d: %w", i, err)
+		}
+
+		perm[i], perm[j] = perm[j], perm[i]
+	}
+
+	return perm, nil
+}
+
+// intReader reads uniform random numbers from a source.
+//
+// It uses the same algorithm as crypto/rand.Int, so it returns the same
+// numbers for the same source. But it does not allocate memory for each
+// number.
+type intReader struct {
+	source io.Reader
+	buf    [8]byte
+}
+
+// intn returns a random number between 0 and max-1.
+func (r *intReader) intn(max int) (int, error) {
+	if max <= 1 {
+		return 0, nil
+	}
+
+	bitLen := bits.Len64(uint64(max - 1))
+	k := (bitLen + 7) / 8
+	b := uint(bitLen % 8)
+	if b == 0 {
+		b = 8
+	}
+
+	buf := r.buf[8-k:]
+	for {
+		r.buf = [8]byte{}
+		if _, err := io.ReadFull(r.source, buf); err != nil {
+			return 0, fmt.Errorf("reading random source: %w", err)
+		}
+
+		buf[0] &= uint8(int(1<<b) - 1)
+
+		n := binary.BigEndian.Uint64(r.buf[:])
+		if n < uint64(max) {
+			return int(n), nil
+		}
+	}
+}
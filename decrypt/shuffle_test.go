@@ -0,0 +1,158 @@
+package decrypt_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/OpenSlides/vote-decrypt/decrypt"
+)
+
+// seededReader is a deterministic random source. It returns sha256(seed ||
+// counter) for an increasing counter.
+type seededReader struct {
+	seed    uint64
+	counter uint64
+	buf     []byte
+}
+
+func (r *seededReader) Read(p []byte) (int, error) {
+	for n := 0; n < len(p); {
+		if len(r.buf) == 0 {
+			var block [16]byte
+			binary.BigEndian.PutUint64(block[:8], r.seed)
+			binary.BigEndian.PutUint64(block[8:], r.counter)
+			r.counter++
+			sum := sha256.Sum256(block[:])
+			r.buf = sum[:]
+		}
+
+		c := copy(p[n:], r.buf)
+		r.buf = r.buf[c:]
+		n += c
+	}
+	return len(p), nil
+}
+
+// shuffledOrders runs Stop `runs` times with the votes `0` to `n-1` and
+// returns the decrypted orders.
+func shuffledOrders(t *testing.T, n int, runs int) [][]string {
+	t.Helper()
+
+	d := decrypt.New(
+		cryptoMock{},
+		NewStoreMock(),
+		decrypt.WithRandomSource(&seededReader{seed: 42}),
+		decrypt.WithListToContent(func(id string, decrypted [][]byte) ([]byte, error) {
+			return json.Marshal(decrypted)
+		}),
+	)
+
+	orders := make([][]string, runs)
+	for run := range orders {
+		pollID := fmt.Sprintf("test/%d", run)
+		if _, _, err := d.Start(context.Background(), pollID); err != nil {
+			t.Fatalf("start: %v", err)
+		}
+
+		votes := make([][]byte, n)
+		for i := range votes {
+			votes[i] = []byte(fmt.Sprintf("enc:%d", i))
+		}
+
+		content, _, err := d.Stop(context.Background(), pollID, votes)
+		if err != nil {
+			t.Fatalf("stop: %v", err)
+		}
+
+		var decrypted [][]byte
+		if err := json.Unmarshal(content, &decrypted); err != nil {
+			t.Fatalf("decoding content: %v", err)
+		}
+
+		order := make([]string, n)
+		for i, vote := range decrypted {
+			order[i] = string(vote)
+		}
+		orders[run] = order
+	}
+	return orders
+}
+
+// chiSquare returns the chi-square statistic for observed counts with the same
+// expected value for each count.
+func chiSquare(observed []int, expected float64) float64 {
+	var sum float64
+	for _, o := range observed {
+		diff := float64(o) - expected
+		sum += diff * diff / expected
+	}
+	return sum
+}
+
+func TestShufflePositions(t *testing.T) {
+	const (
+		n    = 5
+		runs = 5_000
+
+		// Critical value of the chi-square distribution with n-1=4 degrees of
+		// freedom for p=0.001.
+		critical = 18.467
+	)
+
+	// counts[vote][position]
+	counts := make([][]int, n)
+	for i := range counts {
+		counts[i] = make([]int, n)
+	}
+
+	for _, order := range shuffledOrders(t, n, runs) {
+		for pos, vote := range order {
+			var v int
+			if _, err := fmt.Sscan(vote, &v); err != nil {
+				t.Fatalf("invalid vote %q", vote)
+			}
+			counts[v][pos]++
+		}
+	}
+
+	for vote, positions := range counts {
+		if x := chiSquare(positions, runs/n); x > critical {
+			t.Errorf("vote %d: positions %v are biased: chi-square %.2f > %.2f", vote, positions, x, critical)
+		}
+	}
+}
+
+func TestShufflePermutations(t *testing.T) {
+	const (
+		n            = 4
+		permutations = 24
+		runs         = 4_800
+
+		// Critical value of the chi-square distribution with 23 degrees of
+		// freedom for p=0.001.
+		critical = 49.728
+	)
+
+	counts := make(map[string]int)
+	for _, order := range shuffledOrders(t, n, runs) {
+		counts[strings.Join(order, ",")]++
+	}
+
+	if len(counts) != permutations {
+		t.Fatalf("got %d different permutations, expected %d", len(counts), permutations)
+	}
+
+	observed := make([]int, 0, permutations)
+	for _, c := range counts {
+		observed = append(observed, c)
+	}
+
+	if x := chiSquare(observed, runs/permutations); x > critical {
+		t.Errorf("permutations %v are biased: chi-square %.2f > %.2f", counts, x, critical)
+	}
+}
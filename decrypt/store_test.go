@@ -0,0 +1,20 @@
+package decrypt_test
+
+import (
+	"testing"
+
+	"github.com/OpenSlides/vote-decrypt/decrypt"
+	"github.com/OpenSlides/vote-decrypt/store/storetest"
+)
+
+func TestStoreMock(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) decrypt.Store {
+		return NewStoreMock()
+	})
+}
+
+func TestStopResultStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) decrypt.Store {
+		return &stopResultStore{StoreMock: NewStoreMock()}
+	})
+}
@@ -0,0 +1,72 @@
+package decrypt
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/OpenSlides/vote-decrypt/errorcode"
+)
+
+type contextKey int
+
+const (
+	tenantKey contextKey = iota
+	priorityKey
+)
+
+// WithTenant returns a context for requests of the given tenant.
+//
+// The tenant selects the main key. Each tenant can only access polls, which id
+// starts with the tenant name followed by a slash.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey, tenant)
+}
+
+// TenantFromContext returns the tenant from the context. Returns an empty
+// string, if the context has no tenant.
+func TenantFromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey).(string)
+	return tenant
+}
+
+// cryptoFor returns the crypto backend of the tenant from the context.
+//
+// Tenants without an own main key use the default crypto backend.
+func (d *Decrypt) cryptoFor(ctx context.Context) Crypto {
+	return d.tenantCrypto(TenantFromContext(ctx))
+}
+
+// pollCrypto returns the crypto backend for a poll.
+//
+// The tenant is the part of the poll id before the first slash. This is the
+// same namespace, that checkNamespace() enforces. So an admin or a caller
+// without a tenant uses the same key as the tenant of the poll.
+func (d *Decrypt) pollCrypto(pollID string) Crypto {
+	tenant, _, found := strings.Cut(pollID, "/")
+	if !found {
+		return d.crypto
+	}
+	return d.tenantCrypto(tenant)
+}
+
+func (d *Decrypt) tenantCrypto(tenant string) Crypto {
+	if c, ok := d.tenants[tenant]; ok {
+		return c
+	}
+	return d.crypto
+}
+
+// checkNamespace makes sure, that the poll belongs to the tenant from the
+// context.
+func (d *Decrypt) checkNamespace(ctx context.Context, pollID string) error {
+	tenant := TenantFromContext(ctx)
+	if tenant == "" {
+		return nil
+	}
+
+	if !strings.HasPrefix(pollID, tenant+"/") {
+		return fmt.Errorf("poll %s does not belong to tenant %s: %w", pollID, tenant, errorcode.Invalid)
+	}
+	return nil
+}
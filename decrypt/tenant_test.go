@@ -0,0 +1,131 @@
+package decrypt_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/OpenSlides/vote-decrypt/crypto"
+	"github.com/OpenSlides/vote-decrypt/decrypt"
+	"github.com/OpenSlides/vote-decrypt/errorcode"
+)
+
+// tenantCryptoMock is a cryptoMock with an own main key.
+type tenantCryptoMock struct {
+	cryptoMock
+	name string
+}
+
+func (c tenantCryptoMock) PublicMainKey() []byte {
+	return []byte("mainPubKey-" + c.name)
+}
+
+func (c tenantCryptoMock) Sign(value []byte) []byte {
+	return []byte(fmt.Sprintf("sig-%s:%s", c.name, value))
+}
+
+func TestTenant(t *testing.T) {
+	d := decrypt.New(
+		cryptoMock{},
+		NewStoreMock(),
+		decrypt.WithRandomSource(randomMock{}),
+		decrypt.WithTenantCrypto("tenant1", tenantCryptoMock{name: "tenant1"}),
+	)
+
+	tenant1 := decrypt.WithTenant(context.Background(), "tenant1")
+	tenant2 := decrypt.WithTenant(context.Background(), "tenant2")
+
+	t.Run("public main key", func(t *testing.T) {
+		for _, tt := range []struct {
+			name   string
+			ctx    context.Context
+			expect string
+		}{
+			{"no tenant", context.Background(), "mainPubKey"},
+			{"tenant with key", tenant1, "mainPubKey-tenant1"},
+			{"tenant without key", tenant2, "mainPubKey"},
+		} {
+			if got := string(d.PublicMainKey(tt.ctx)); got != tt.expect {
+				t.Errorf("%s: got main key %s, expected %s", tt.name, got, tt.expect)
+			}
+		}
+	})
+
+	t.Run("stop signs with tenant key", func(t *testing.T) {
+		if _, _, err := d.Start(tenant1, "tenant1/1"); err != nil {
+			t.Fatalf("start: %v", err)
+		}
+
+		content, signature, err := d.Stop(tenant1, "tenant1/1", [][]byte{[]byte(`enc:"Y"`)})
+		if err != nil {
+			t.Fatalf("stop: %v", err)
+		}
+
+		if expect := "sig-tenant1:" + string(content); string(signature) != expect {
+			t.Errorf("got signature %s, expected %s", signature, expect)
+		}
+	})
+
+	t.Run("other namespace", func(t *testing.T) {
+		if _, _, err := d.Start(tenant1, "tenant2/1"); !errors.Is(err, errorcode.Invalid) {
+			t.Errorf("start returned `%v`, expected `%v`", err, errorcode.Invalid)
+		}
+
+		if err := d.Clear(tenant1, "tenant2/1"); !errors.Is(err, errorcode.Invalid) {
+			t.Errorf("clear returned `%v`, expected `%v`", err, errorcode.Invalid)
+		}
+	})
+
+	t.Run("list polls", func(t *testing.T) {
+		if _, _, err := d.Start(tenant2, "tenant2/1"); err != nil {
+			t.Fatalf("start: %v", err)
+		}
+
+		polls, err := d.ListPolls(tenant2)
+		if err != nil {
+			t.Fatalf("list polls: %v", err)
+		}
+
+		if len(polls) != 1 || polls[0].ID != "tenant2/1" {
+			t.Errorf("got polls %v, expected only tenant2/1", polls)
+		}
+	})
+}
+
+func TestTenantKeyForAdmin(t *testing.T) {
+	tenantMainKey := bytes.Repeat([]byte{1}, 32)
+	d := decrypt.New(
+		crypto.New(make([]byte, 32), rand.Reader, nil),
+		NewStoreMock(),
+		decrypt.WithTenantCrypto("tenant1", crypto.New(tenantMainKey, rand.Reader, nil)),
+	)
+
+	// An admin or an unauthenticated caller has no tenant in the context.
+	ctx := context.Background()
+	tenantPubKey := d.PublicMainKey(decrypt.WithTenant(ctx, "tenant1"))
+
+	pubKey, pubKeySig, err := d.Start(ctx, "tenant1/1")
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	if !crypto.Verify(tenantPubKey, pubKey, pubKeySig) {
+		t.Errorf("public poll key is not signed with the tenant key")
+	}
+
+	content, signature, err := d.Stop(ctx, "tenant1/1", nil)
+	if err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+
+	if !crypto.Verify(tenantPubKey, content, signature) {
+		t.Errorf("stop result is not signed with the tenant key")
+	}
+
+	if crypto.Verify(d.PublicMainKey(ctx), content, signature) {
+		t.Errorf("stop result is signed with the default key")
+	}
+}
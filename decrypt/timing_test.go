@@ -0,0 +1,90 @@
+package decrypt_test
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"fmt"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/OpenSlides/vote-decrypt/crypto"
+	"github.com/OpenSlides/vote-decrypt/decrypt"
+	"github.com/OpenSlides/vote-decrypt/internal/dudect"
+	"github.com/OpenSlides/vote-decrypt/store"
+)
+
+// TestUnknownPollTiming measures, that Stop and Clear take the same time for
+// unknown and for known polls with the file store. It takes some time and can
+// fail on a busy machine. So it only runs, when the environment variable
+// VOTE_DECRYPT_TIMING_TEST is set.
+func TestUnknownPollTiming(t *testing.T) {
+	if os.Getenv("VOTE_DECRYPT_TIMING_TEST") == "" {
+		t.Skip("set VOTE_DECRYPT_TIMING_TEST to run the timing test")
+	}
+
+	ctx := context.Background()
+	d := decrypt.New(crypto.New(make([]byte, 32), rand.Reader, nil), store.New(t.TempDir()))
+
+	pubKey, _, err := d.Start(ctx, "test/known")
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	votes := make([][]byte, 5)
+	for i := range votes {
+		votes[i], err = crypto.Encrypt(rand.Reader, ecdh.X25519(), pubKey, []byte(`"Y"`))
+		if err != nil {
+			t.Fatalf("encrypting vote: %v", err)
+		}
+	}
+
+	// The first stop saves the state. All later calls only compare the
+	// signature.
+	if _, _, err := d.Stop(ctx, "test/known", votes); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+
+	t.Run("stop", func(t *testing.T) {
+		got := dudect.Measure(2_000, func(class int) func() {
+			pollID := "test/known"
+			if class == 1 {
+				pollID = "test/unknown"
+			}
+			return func() { d.Stop(ctx, pollID, votes) }
+		})
+
+		if math.Abs(got) > dudect.Threshold {
+			t.Errorf("got t=%.2f, the time of Stop on an unknown poll differs from a known one", got)
+		}
+		t.Logf("t=%.2f", got)
+	})
+
+	t.Run("clear", func(t *testing.T) {
+		const samples = 2_000
+
+		// The polls are started before the measurement, so the time of
+		// Clear does not contain writing the files of Start to the disk.
+		for i := 0; i < samples; i++ {
+			if _, _, err := d.Start(ctx, fmt.Sprintf("test/known.%d", i)); err != nil {
+				t.Fatalf("start: %v", err)
+			}
+		}
+
+		var n int
+		got := dudect.Measure(samples, func(class int) func() {
+			n++
+			pollID := fmt.Sprintf("test/unknown.%d", n)
+			if class == 0 {
+				pollID = fmt.Sprintf("test/known.%d", n)
+			}
+			return func() { d.Clear(ctx, pollID) }
+		})
+
+		if math.Abs(got) > dudect.Threshold {
+			t.Errorf("got t=%.2f, the time of Clear on an unknown poll differs from a known one", got)
+		}
+		t.Logf("t=%.2f", got)
+	})
+}
@@ -0,0 +1,33 @@
+package decrypt
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/OpenSlides/vote-decrypt/decrypt"
+
+// startSpan starts a span with the attributes of the poll.
+func (d *Decrypt) startSpan(ctx context.Context, name string, pollID string) (context.Context, trace.Span) {
+	return d.tracer.Start(ctx, name, trace.WithAttributes(attribute.String("poll.id", pollID)))
+}
+
+// inSpan runs f in a child span of the span from ctx.
+func (d *Decrypt) inSpan(ctx context.Context, name string, f func(ctx context.Context) error) error {
+	ctx, span := d.tracer.Start(ctx, name)
+	err := f(ctx)
+	endSpan(span, err)
+	return err
+}
+
+// endSpan ends the span and records the error, if it is not nil.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,83 @@
+package decrypt_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/OpenSlides/vote-decrypt/decrypt"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	d := decrypt.New(
+		cryptoMock{},
+		NewStoreMock(),
+		decrypt.WithRandomSource(randomMock{}),
+		decrypt.WithTracerProvider(provider),
+	)
+
+	if _, _, err := d.Start(context.Background(), "test/1"); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	if _, _, err := d.Stop(context.Background(), "test/1", [][]byte{[]byte(`enc:"Y"`)}); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+
+	// Create a set of "SPAN/PARENT" to check the structure of the spans.
+	spans := exporter.GetSpans()
+	got := make(map[string]bool)
+	for _, span := range spans {
+		parent := ""
+		for _, p := range spans {
+			if p.SpanContext.SpanID() == span.Parent.SpanID() {
+				parent = p.Name
+			}
+		}
+		got[span.Name+"/"+parent] = true
+	}
+
+	for _, expect := range []string{
+		"decrypt.Start/",
+		"load key/decrypt.Start",
+		"sign public key/decrypt.Start",
+		"decrypt.Stop/",
+		"load key/decrypt.Stop",
+		"decrypt votes/decrypt.Stop",
+		"build content/decrypt.Stop",
+		"sign content/decrypt.Stop",
+		"validate signature/decrypt.Stop",
+	} {
+		if !got[expect] {
+			t.Errorf("span %s not found", expect)
+		}
+	}
+}
+
+func TestTracingError(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	d := decrypt.New(cryptoMock{}, NewStoreMock(), decrypt.WithTracerProvider(provider))
+
+	if _, _, err := d.Stop(context.Background(), "test/1", nil); err == nil {
+		t.Fatalf("stop on unknown poll did not return an error")
+	}
+
+	// An unknown poll runs all steps, so the step spans exist too. Only the
+	// span of Stop has the error.
+	var withError []string
+	for _, span := range exporter.GetSpans() {
+		if len(span.Events) > 0 {
+			withError = append(withError, span.Name)
+		}
+	}
+
+	if len(withError) != 1 || withError[0] != "decrypt.Stop" {
+		t.Errorf("got spans with errors %v, expected only decrypt.Stop", withError)
+	}
+}
@@ -19,6 +19,14 @@ const (
 	//
 	// Has to be returned by store.ValidateHash if the hash is invalid.
 	Invalid
+
+	// WrongState happens when the state of a poll does not allow the
+	// operation. For example, when a stopped poll is started again.
+	WrongState
+
+	// Exhausted happens when a limit of the service is reached. For example,
+	// when a poll has more votes then allowed.
+	Exhausted
 )
 
 // DecryptError are all known errors from the decrypt error.
@@ -35,6 +43,12 @@ func (err DecryptError) Error() string {
 	case Invalid:
 		return "invalid content"
 
+	case WrongState:
+		return "operation not allowed in the current poll state"
+
+	case Exhausted:
+		return "limit exceeded"
+
 	default:
 		return "unknown error"
 	}
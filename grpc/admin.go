@@ -0,0 +1,74 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/OpenSlides/vote-decrypt/decrypt"
+	"github.com/OpenSlides/vote-decrypt/logging"
+)
+
+// healthError is the message of an unhealthy HealthResponse. The details are
+// in the log of the server.
+const healthError = "service is not ready"
+
+type adminServer struct {
+	decrypt *decrypt.Decrypt
+	logger  *slog.Logger
+}
+
+func (s adminServer) ListPolls(ctx context.Context, req *EmptyMessage) (*ListPollsResponse, error) {
+	s.logger.InfoContext(ctx, "admin list polls request")
+	polls, err := s.decrypt.ListPolls(ctx)
+	if err != nil {
+		return nil, grpcError(ctx, s.logger, fmt.Errorf("listing polls: %w", err))
+	}
+
+	resp := ListPollsResponse{Polls: make([]*GetPollResponse, len(polls))}
+	for i, poll := range polls {
+		resp.Polls[i] = pollToResponse(poll)
+	}
+	return &resp, nil
+}
+
+func (s adminServer) GetPoll(ctx context.Context, req *GetPollRequest) (*GetPollResponse, error) {
+	s.logger.InfoContext(ctx, "admin get poll request", "poll_id", req.Id)
+	ctx, err := tenantContext(ctx, req.Tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	poll, err := s.decrypt.GetPoll(ctx, req.Id)
+	if err != nil {
+		return nil, grpcError(ctx, s.logger, fmt.Errorf("getting poll: %w", err))
+	}
+
+	return pollToResponse(poll), nil
+}
+
+// ForceClear clears a poll in any state.
+func (s adminServer) ForceClear(ctx context.Context, req *ClearRequest) (*EmptyMessage, error) {
+	s.logger.InfoContext(ctx, "admin force clear request", "poll_id", req.Id)
+	ctx, err := tenantContext(ctx, req.Tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.decrypt.Clear(ctx, req.Id); err != nil {
+		return nil, grpcError(ctx, s.logger, fmt.Errorf("clearing poll: %w", err))
+	}
+
+	return new(EmptyMessage), nil
+}
+
+func (s adminServer) Health(ctx context.Context, req *EmptyMessage) (*HealthResponse, error) {
+	if err := s.decrypt.Health(ctx); err != nil {
+		// The error can contain paths or urls of the store. So it is only
+		// logged.
+		s.logger.WarnContext(ctx, "health check failed", "error_class", logging.ErrorClass(err), "error", err)
+		return &HealthResponse{Healthy: false, Error: healthError}, nil
+	}
+
+	return &HealthResponse{Healthy: true}, nil
+}
@@ -0,0 +1,127 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/OpenSlides/vote-decrypt/auth"
+	"github.com/OpenSlides/vote-decrypt/decrypt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// authInterceptor returns an interceptor, that authenticates the caller and
+// makes sure, that the caller only accesses its own polls.
+//
+// The tenant of the caller is saved in the context of the request.
+func authInterceptor(tokens auth.Tokens, certAuth bool) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		// Health checks are used by probes, that have no credentials.
+		if strings.HasPrefix(info.FullMethod, "/grpc.health.v1.Health/") {
+			return handler(ctx, req)
+		}
+
+		tenant, err := authenticate(ctx, tokens, certAuth)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+
+		if strings.HasPrefix(info.FullMethod, "/Admin/") && tenant != auth.Admin {
+			return nil, status.Error(codes.PermissionDenied, "admin service is only allowed for admins")
+		}
+
+		if r, ok := req.(interface{ GetId() string }); ok && !auth.Allowed(tenant, r.GetId()) {
+			return nil, status.Errorf(codes.PermissionDenied, "poll id has to start with %s/", tenant)
+		}
+
+		return handler(auth.WithTenant(ctx, tenant), req)
+	}
+}
+
+// authenticate returns the tenant of the caller.
+//
+// It uses the bearer token from the authorization header. If no token is
+// given and certAuth is true, the common name of the client certificate is
+// used.
+func authenticate(ctx context.Context, tokens auth.Tokens, certAuth bool) (string, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get("authorization") {
+		token, found := strings.CutPrefix(value, "Bearer ")
+		if !found {
+			continue
+		}
+
+		tenant, ok := tokens.Tenant(token)
+		if !ok {
+			return "", fmt.Errorf("invalid token")
+		}
+		return tenant, nil
+	}
+
+	if certAuth {
+		p, ok := peer.FromContext(ctx)
+		if !ok {
+			return "", fmt.Errorf("no peer information")
+		}
+
+		tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+		if ok && len(tlsInfo.State.VerifiedChains) > 0 && len(tlsInfo.State.VerifiedChains[0]) > 0 {
+			tenant := tlsInfo.State.VerifiedChains[0][0].Subject.CommonName
+			if err := auth.ValidateTenant(tenant); err != nil {
+				return "", fmt.Errorf("invalid common name in client certificate: %w", err)
+			}
+			return tenant, nil
+		}
+	}
+
+	return "", fmt.Errorf("no credentials")
+}
+
+// tenantContext returns a context with the tenant for the decrypt service.
+//
+// The tenant is the requested tenant from the message or the authenticated
+// tenant. Only admins can request another tenant then there own.
+func tenantContext(ctx context.Context, requested string) (context.Context, error) {
+	caller, authenticated := auth.FromContext(ctx)
+
+	tenant := requested
+	if tenant == "" && authenticated && caller != auth.Admin {
+		tenant = caller
+	}
+
+	if tenant == "" {
+		return ctx, nil
+	}
+
+	if err := auth.ValidateTenant(tenant); err != nil || tenant == auth.Admin {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid tenant %q", tenant)
+	}
+
+	if authenticated && caller != auth.Admin && tenant != caller {
+		return nil, status.Error(codes.PermissionDenied, "tenant does not match the authenticated tenant")
+	}
+
+	return decrypt.WithTenant(ctx, tenant), nil
+}
+
+// tokenCredentials sends a bearer token with each request.
+type tokenCredentials struct {
+	token    string
+	insecure bool
+}
+
+func (t tokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + t.token}, nil
+}
+
+// RequireTransportSecurity returns true, so the token is not sent in
+// cleartext. It returns false, if insecure tokens were allowed with
+// WithInsecureToken().
+func (t tokenCredentials) RequireTransportSecurity() bool {
+	return !t.insecure
+}
@@ -0,0 +1,285 @@
+package grpc_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OpenSlides/vote-decrypt/auth"
+	"github.com/OpenSlides/vote-decrypt/crypto"
+	"github.com/OpenSlides/vote-decrypt/decrypt"
+	"github.com/OpenSlides/vote-decrypt/grpc"
+	"github.com/OpenSlides/vote-decrypt/store"
+	ggrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+func TestTokenAuth(t *testing.T) {
+	tokens, err := auth.ParseTokens(strings.NewReader("instance1 token1\n* admintoken"))
+	if err != nil {
+		t.Fatalf("parsing tokens: %v", err)
+	}
+
+	_, addr := runServer(t, grpc.WithTokens(tokens), grpc.WithInsecureTokens())
+	ctx := context.Background()
+
+	for _, tt := range []struct {
+		name   string
+		token  string
+		pollID string
+		expect codes.Code
+	}{
+		{"no token", "", "instance1/1", codes.Unauthenticated},
+		{"invalid token", "wrong", "instance1/1", codes.Unauthenticated},
+		{"valid", "token1", "instance1/1", codes.OK},
+		{"other namespace", "token1", "instance2/1", codes.PermissionDenied},
+		{"no namespace", "token1", "1", codes.PermissionDenied},
+		{"admin", "admintoken", "instance2/1", codes.OK},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var options []grpc.ClientOption
+			if tt.token != "" {
+				options = append(options, grpc.WithToken(tt.token), grpc.WithInsecureToken())
+			}
+
+			client, close, err := grpc.NewClient(addr, options...)
+			if err != nil {
+				t.Fatalf("NewClient: %v", err)
+			}
+			defer close()
+
+			_, _, err = client.Start(ctx, tt.pollID)
+			if got := status.Code(errors.Unwrap(err)); got != tt.expect {
+				t.Errorf("Start returned code %s, expected %s: %v", got, tt.expect, err)
+			}
+		})
+	}
+}
+
+func TestTenant(t *testing.T) {
+	tokens, err := auth.ParseTokens(strings.NewReader("instance1 token1\n* admintoken"))
+	if err != nil {
+		t.Fatalf("parsing tokens: %v", err)
+	}
+
+	d, addr := runServer(t, grpc.WithTokens(tokens), grpc.WithInsecureTokens())
+	ctx := context.Background()
+	defaultKey := d.PublicMainKey(ctx)
+
+	for _, tt := range []struct {
+		name   string
+		token  string
+		tenant string
+		expect codes.Code
+	}{
+		{"authenticated tenant", "token1", "", codes.OK},
+		{"same tenant", "token1", "instance1", codes.OK},
+		{"other tenant", "token1", "instance2", codes.PermissionDenied},
+		{"admin with tenant", "admintoken", "instance2", codes.OK},
+		{"invalid tenant", "admintoken", "*", codes.InvalidArgument},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			client, close, err := grpc.NewClient(addr, grpc.WithToken(tt.token), grpc.WithInsecureToken(), grpc.WithTenant(tt.tenant))
+			if err != nil {
+				t.Fatalf("NewClient: %v", err)
+			}
+			defer close()
+
+			key, err := client.PublicMainKey(ctx)
+			if got := status.Code(errors.Unwrap(err)); got != tt.expect {
+				t.Fatalf("PublicMainKey returned code %s, expected %s: %v", got, tt.expect, err)
+			}
+
+			if err == nil && string(key) != string(defaultKey) {
+				t.Errorf("got main key %x, expected the default key %x", key, defaultKey)
+			}
+		})
+	}
+}
+
+func TestTokenAuthAdmin(t *testing.T) {
+	tokens, err := auth.ParseTokens(strings.NewReader("instance1 token1\n* admintoken"))
+	if err != nil {
+		t.Fatalf("parsing tokens: %v", err)
+	}
+
+	_, addr := runServer(t, grpc.WithTokens(tokens), grpc.WithInsecureTokens())
+
+	for token, expect := range map[string]codes.Code{
+		"token1":     codes.PermissionDenied,
+		"admintoken": codes.OK,
+	} {
+		conn, err := ggrpc.Dial(
+			addr,
+			ggrpc.WithTransportCredentials(insecure.NewCredentials()),
+			ggrpc.WithPerRPCCredentials(bearer(token)),
+		)
+		if err != nil {
+			t.Fatalf("connecting to admin service: %v", err)
+		}
+		defer conn.Close()
+
+		_, err = grpc.NewAdminClient(conn).ListPolls(context.Background(), &grpc.EmptyMessage{})
+		if got := status.Code(err); got != expect {
+			t.Errorf("ListPolls with token %s returned code %s, expected %s", token, got, expect)
+		}
+	}
+}
+
+func TestTokenRequiresTLS(t *testing.T) {
+	tokens, err := auth.ParseTokens(strings.NewReader("instance1 token1"))
+	if err != nil {
+		t.Fatalf("parsing tokens: %v", err)
+	}
+
+	t.Run("server without tls", func(t *testing.T) {
+		d := decrypt.New(crypto.New(make([]byte, 32), rand.Reader, nil), store.New(t.TempDir()))
+
+		if err := grpc.RunServer(context.Background(), d, freeAddr(t), grpc.WithTokens(tokens)); err == nil {
+			t.Errorf("RunServer with tokens without tls did not return an error")
+		}
+	})
+
+	t.Run("client without tls", func(t *testing.T) {
+		_, addr := runServer(t, grpc.WithTokens(tokens), grpc.WithInsecureTokens())
+
+		client, close, err := grpc.NewClient(addr, grpc.WithToken("token1"))
+		if err != nil {
+			// grpc can also reject the credentials when the connection is
+			// created.
+			return
+		}
+		defer close()
+
+		if _, _, err := client.Start(context.Background(), "instance1/1"); err == nil {
+			t.Errorf("client sent the token without tls")
+		}
+	})
+
+	t.Run("with tls", func(t *testing.T) {
+		ca, caKey := testCertificate(t, "ca", nil, nil)
+		server, serverKey := testCertificate(t, "localhost", ca, caKey)
+
+		pool := x509.NewCertPool()
+		pool.AddCert(ca)
+
+		_, addr := runServer(
+			t,
+			grpc.WithTokens(tokens),
+			grpc.WithTLS(&tls.Config{
+				Certificates: []tls.Certificate{{Certificate: [][]byte{server.Raw}, PrivateKey: serverKey}},
+			}),
+		)
+
+		client, close, err := grpc.NewClient(
+			addr,
+			grpc.WithToken("token1"),
+			grpc.WithClientTLS(&tls.Config{RootCAs: pool, ServerName: "localhost"}),
+		)
+		if err != nil {
+			t.Fatalf("NewClient: %v", err)
+		}
+		defer close()
+
+		if _, _, err := client.Start(context.Background(), "instance1/1"); err != nil {
+			t.Errorf("Start: %v", err)
+		}
+	})
+}
+
+type bearer string
+
+func (b bearer) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(b)}, nil
+}
+
+func (b bearer) RequireTransportSecurity() bool {
+	return false
+}
+
+func TestCertAuth(t *testing.T) {
+	ca, caKey := testCertificate(t, "ca", nil, nil)
+	server, serverKey := testCertificate(t, "localhost", ca, caKey)
+	client, clientKey := testCertificate(t, "instance1", ca, caKey)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+
+	_, addr := runServer(t, grpc.WithTLS(&tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{server.Raw}, PrivateKey: serverKey}},
+		ClientCAs:    pool,
+		ClientAuth:   tls.VerifyClientCertIfGiven,
+	}))
+
+	c, close, err := grpc.NewClient(addr, grpc.WithClientTLS(&tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{client.Raw}, PrivateKey: clientKey}},
+		RootCAs:      pool,
+		ServerName:   "localhost",
+	}))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	defer close()
+
+	ctx := context.Background()
+	if _, _, err := c.Start(ctx, "instance1/1"); err != nil {
+		t.Errorf("Start: %v", err)
+	}
+
+	_, _, err = c.Start(ctx, "instance2/1")
+	if got := status.Code(errors.Unwrap(err)); got != codes.PermissionDenied {
+		t.Errorf("Start in other namespace returned code %s, expected %s", got, codes.PermissionDenied)
+	}
+}
+
+// testCertificate creates a certificate. If parent is nil, a self signed CA is
+// created.
+func testCertificate(t *testing.T, commonName string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:     []string{commonName},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		parent = template
+		parentKey = key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("creating certificate: %v", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parsing certificate: %v", err)
+	}
+	return cert, key
+}
@@ -18,10 +18,11 @@ const _ = grpc.SupportPackageIsVersion7
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type DecryptClient interface {
-	PublicMainKey(ctx context.Context, in *EmptyMessage, opts ...grpc.CallOption) (*PublicMainKeyResponse, error)
+	PublicMainKey(ctx context.Context, in *PublicMainKeyRequest, opts ...grpc.CallOption) (*PublicMainKeyResponse, error)
 	Start(ctx context.Context, in *StartRequest, opts ...grpc.CallOption) (*StartResponse, error)
 	Stop(ctx context.Context, in *StopRequest, opts ...grpc.CallOption) (*StopResponse, error)
 	Clear(ctx context.Context, in *ClearRequest, opts ...grpc.CallOption) (*EmptyMessage, error)
+	GetPoll(ctx context.Context, in *GetPollRequest, opts ...grpc.CallOption) (*GetPollResponse, error)
 }
 
 type decryptClient struct {
@@ -32,7 +33,7 @@ func NewDecryptClient(cc grpc.ClientConnInterface) DecryptClient {
 	return &decryptClient{cc}
 }
 
-func (c *decryptClient) PublicMainKey(ctx context.Context, in *EmptyMessage, opts ...grpc.CallOption) (*PublicMainKeyResponse, error) {
+func (c *decryptClient) PublicMainKey(ctx context.Context, in *PublicMainKeyRequest, opts ...grpc.CallOption) (*PublicMainKeyResponse, error) {
 	out := new(PublicMainKeyResponse)
 	err := c.cc.Invoke(ctx, "/Decrypt/PublicMainKey", in, out, opts...)
 	if err != nil {
@@ -68,21 +69,31 @@ func (c *decryptClient) Clear(ctx context.Context, in *ClearRequest, opts ...grp
 	return out, nil
 }
 
+func (c *decryptClient) GetPoll(ctx context.Context, in *GetPollRequest, opts ...grpc.CallOption) (*GetPollResponse, error) {
+	out := new(GetPollResponse)
+	err := c.cc.Invoke(ctx, "/Decrypt/GetPoll", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // DecryptServer is the server API for Decrypt service.
 // All implementations should embed UnimplementedDecryptServer
 // for forward compatibility
 type DecryptServer interface {
-	PublicMainKey(context.Context, *EmptyMessage) (*PublicMainKeyResponse, error)
+	PublicMainKey(context.Context, *PublicMainKeyRequest) (*PublicMainKeyResponse, error)
 	Start(context.Context, *StartRequest) (*StartResponse, error)
 	Stop(context.Context, *StopRequest) (*StopResponse, error)
 	Clear(context.Context, *ClearRequest) (*EmptyMessage, error)
+	GetPoll(context.Context, *GetPollRequest) (*GetPollResponse, error)
 }
 
 // UnimplementedDecryptServer should be embedded to have forward compatible implementations.
 type UnimplementedDecryptServer struct {
 }
 
-func (UnimplementedDecryptServer) PublicMainKey(context.Context, *EmptyMessage) (*PublicMainKeyResponse, error) {
+func (UnimplementedDecryptServer) PublicMainKey(context.Context, *PublicMainKeyRequest) (*PublicMainKeyResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method PublicMainKey not implemented")
 }
 func (UnimplementedDecryptServer) Start(context.Context, *StartRequest) (*StartResponse, error) {
@@ -94,6 +105,9 @@ func (UnimplementedDecryptServer) Stop(context.Context, *StopRequest) (*StopResp
 func (UnimplementedDecryptServer) Clear(context.Context, *ClearRequest) (*EmptyMessage, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Clear not implemented")
 }
+func (UnimplementedDecryptServer) GetPoll(context.Context, *GetPollRequest) (*GetPollResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPoll not implemented")
+}
 
 // UnsafeDecryptServer may be embedded to opt out of forward compatibility for this service.
 // Use of this interface is not recommended, as added methods to DecryptServer will
@@ -107,7 +121,7 @@ func RegisterDecryptServer(s grpc.ServiceRegistrar, srv DecryptServer) {
 }
 
 func _Decrypt_PublicMainKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(EmptyMessage)
+	in := new(PublicMainKeyRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -119,7 +133,7 @@ func _Decrypt_PublicMainKey_Handler(srv interface{}, ctx context.Context, dec fu
 		FullMethod: "/Decrypt/PublicMainKey",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(DecryptServer).PublicMainKey(ctx, req.(*EmptyMessage))
+		return srv.(DecryptServer).PublicMainKey(ctx, req.(*PublicMainKeyRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -178,6 +192,24 @@ func _Decrypt_Clear_Handler(srv interface{}, ctx context.Context, dec func(inter
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Decrypt_GetPoll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPollRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DecryptServer).GetPoll(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Decrypt/GetPoll",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DecryptServer).GetPoll(ctx, req.(*GetPollRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Decrypt_ServiceDesc is the grpc.ServiceDesc for Decrypt service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -201,6 +233,202 @@ var Decrypt_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Clear",
 			Handler:    _Decrypt_Clear_Handler,
 		},
+		{
+			MethodName: "GetPoll",
+			Handler:    _Decrypt_GetPoll_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "grpc/decrypt.proto",
+}
+
+// AdminClient is the client API for Admin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AdminClient interface {
+	ListPolls(ctx context.Context, in *EmptyMessage, opts ...grpc.CallOption) (*ListPollsResponse, error)
+	GetPoll(ctx context.Context, in *GetPollRequest, opts ...grpc.CallOption) (*GetPollResponse, error)
+	ForceClear(ctx context.Context, in *ClearRequest, opts ...grpc.CallOption) (*EmptyMessage, error)
+	Health(ctx context.Context, in *EmptyMessage, opts ...grpc.CallOption) (*HealthResponse, error)
+}
+
+type adminClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAdminClient(cc grpc.ClientConnInterface) AdminClient {
+	return &adminClient{cc}
+}
+
+func (c *adminClient) ListPolls(ctx context.Context, in *EmptyMessage, opts ...grpc.CallOption) (*ListPollsResponse, error) {
+	out := new(ListPollsResponse)
+	err := c.cc.Invoke(ctx, "/Admin/ListPolls", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) GetPoll(ctx context.Context, in *GetPollRequest, opts ...grpc.CallOption) (*GetPollResponse, error) {
+	out := new(GetPollResponse)
+	err := c.cc.Invoke(ctx, "/Admin/GetPoll", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) ForceClear(ctx context.Context, in *ClearRequest, opts ...grpc.CallOption) (*EmptyMessage, error) {
+	out := new(EmptyMessage)
+	err := c.cc.Invoke(ctx, "/Admin/ForceClear", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) Health(ctx context.Context, in *EmptyMessage, opts ...grpc.CallOption) (*HealthResponse, error) {
+	out := new(HealthResponse)
+	err := c.cc.Invoke(ctx, "/Admin/Health", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AdminServer is the server API for Admin service.
+// All implementations should embed UnimplementedAdminServer
+// for forward compatibility
+type AdminServer interface {
+	ListPolls(context.Context, *EmptyMessage) (*ListPollsResponse, error)
+	GetPoll(context.Context, *GetPollRequest) (*GetPollResponse, error)
+	ForceClear(context.Context, *ClearRequest) (*EmptyMessage, error)
+	Health(context.Context, *EmptyMessage) (*HealthResponse, error)
+}
+
+// UnimplementedAdminServer should be embedded to have forward compatible implementations.
+type UnimplementedAdminServer struct {
+}
+
+func (UnimplementedAdminServer) ListPolls(context.Context, *EmptyMessage) (*ListPollsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPolls not implemented")
+}
+func (UnimplementedAdminServer) GetPoll(context.Context, *GetPollRequest) (*GetPollResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPoll not implemented")
+}
+func (UnimplementedAdminServer) ForceClear(context.Context, *ClearRequest) (*EmptyMessage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ForceClear not implemented")
+}
+func (UnimplementedAdminServer) Health(context.Context, *EmptyMessage) (*HealthResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Health not implemented")
+}
+
+// UnsafeAdminServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AdminServer will
+// result in compilation errors.
+type UnsafeAdminServer interface {
+	mustEmbedUnimplementedAdminServer()
+}
+
+func RegisterAdminServer(s grpc.ServiceRegistrar, srv AdminServer) {
+	s.RegisterService(&Admin_ServiceDesc, srv)
+}
+
+func _Admin_ListPolls_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EmptyMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).ListPolls(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Admin/ListPolls",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).ListPolls(ctx, req.(*EmptyMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_GetPoll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPollRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).GetPoll(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Admin/GetPoll",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).GetPoll(ctx, req.(*GetPollRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_ForceClear_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClearRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).ForceClear(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Admin/ForceClear",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).ForceClear(ctx, req.(*ClearRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_Health_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EmptyMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).Health(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Admin/Health",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).Health(ctx, req.(*EmptyMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Admin_ServiceDesc is the grpc.ServiceDesc for Admin service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Admin_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "Admin",
+	HandlerType: (*AdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListPolls",
+			Handler:    _Admin_ListPolls_Handler,
+		},
+		{
+			MethodName: "GetPoll",
+			Handler:    _Admin_GetPoll_Handler,
+		},
+		{
+			MethodName: "ForceClear",
+			Handler:    _Admin_ForceClear_Handler,
+		},
+		{
+			MethodName: "Health",
+			Handler:    _Admin_Health_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "grpc/decrypt.proto",
@@ -0,0 +1,259 @@
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// openAPISpec describes the http gateway.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// gatewayMethod is a method of the Decrypt service, that can be called with
+// the http gateway.
+type gatewayMethod struct {
+	newRequest func() proto.Message
+	call       func(ctx context.Context, req proto.Message) (proto.Message, error)
+}
+
+// gateway serves the methods of the Decrypt service as JSON over http.
+//
+// Each request runs through the same interceptors as a grpc request, so it
+// uses the same authentication and metrics.
+type gateway struct {
+	methods     map[string]gatewayMethod
+	interceptor grpc.UnaryServerInterceptor
+	maxBody     int64
+}
+
+func newGateway(server grpcServer, interceptors []grpc.UnaryServerInterceptor, maxBody int) http.Handler {
+	g := gateway{
+		methods: map[string]gatewayMethod{
+			"PublicMainKey": {
+				newRequest: func() proto.Message { return new(PublicMainKeyRequest) },
+				call: func(ctx context.Context, req proto.Message) (proto.Message, error) {
+					return server.PublicMainKey(ctx, req.(*PublicMainKeyRequest))
+				},
+			},
+			"Start": {
+				newRequest: func() proto.Message { return new(StartRequest) },
+				call: func(ctx context.Context, req proto.Message) (proto.Message, error) {
+					return server.Start(ctx, req.(*StartRequest))
+				},
+			},
+			"Stop": {
+				newRequest: func() proto.Message { return new(StopRequest) },
+				call: func(ctx context.Context, req proto.Message) (proto.Message, error) {
+					return server.Stop(ctx, req.(*StopRequest))
+				},
+			},
+			"Clear": {
+				newRequest: func() proto.Message { return new(ClearRequest) },
+				call: func(ctx context.Context, req proto.Message) (proto.Message, error) {
+					return server.Clear(ctx, req.(*ClearRequest))
+				},
+			},
+		},
+		interceptor: chainInterceptors(interceptors),
+		maxBody:     int64(maxBody),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /Decrypt/{method}", g.handleMethod)
+	mux.HandleFunc("GET /openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(openAPISpec)
+	})
+
+	return otelhttp.NewHandler(mux, "gateway")
+}
+
+func (g gateway) handleMethod(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("method")
+	method, ok := g.methods[name]
+	if !ok {
+		writeGatewayError(w, status.Errorf(codes.NotFound, "unknown method %s", name))
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, g.maxBody))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeGatewayError(w, status.Errorf(codes.ResourceExhausted, "request body larger then %d bytes", g.maxBody))
+			return
+		}
+		writeGatewayError(w, status.Errorf(codes.InvalidArgument, "reading body: %v", err))
+		return
+	}
+
+	req := method.newRequest()
+	if len(body) > 0 {
+		if err := protojson.Unmarshal(body, req); err != nil {
+			writeGatewayError(w, status.Errorf(codes.InvalidArgument, "invalid body: %v", err))
+			return
+		}
+	}
+
+	handler := func(ctx context.Context, req any) (any, error) {
+		return method.call(ctx, req.(proto.Message))
+	}
+
+	info := &grpc.UnaryServerInfo{Server: g, FullMethod: "/Decrypt/" + name}
+	resp, err := g.interceptor(gatewayContext(r), req, info, handler)
+	if err != nil {
+		writeGatewayError(w, err)
+		return
+	}
+
+	encoded, err := protojson.Marshal(resp.(proto.Message))
+	if err != nil {
+		writeGatewayError(w, status.Errorf(codes.Internal, "encoding response: %v", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(encoded)
+}
+
+// gatewayContext returns a context, that contains the credentials of the
+// http request like the context of a grpc request.
+func gatewayContext(r *http.Request) context.Context {
+	ctx := r.Context()
+
+	if authorization := r.Header.Values("Authorization"); len(authorization) > 0 {
+		ctx = metadata.NewIncomingContext(ctx, metadata.MD{"authorization": authorization})
+	}
+
+	if r.TLS != nil {
+		ctx = peer.NewContext(ctx, &peer.Peer{
+			Addr:     remoteAddr(r.RemoteAddr),
+			AuthInfo: credentials.TLSInfo{State: *r.TLS},
+		})
+	}
+
+	return ctx
+}
+
+// remoteAddr converts the remote address of a http request to a net.Addr.
+func remoteAddr(addr string) net.Addr {
+	tcpAddr, err := net.ResolveTCPAddr("tcp", addr)
+	if err != nil {
+		return nil
+	}
+	return tcpAddr
+}
+
+// writeGatewayError writes a grpc error as json with the matching http status
+// code.
+func writeGatewayError(w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpStatus(st.Code()))
+	fmt.Fprintf(w, `{"code":%q,"message":%q}`, st.Code().String(), st.Message())
+}
+
+// httpStatus returns the http status code for a grpc code.
+func httpStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.InvalidArgument:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists:
+		return http.StatusConflict
+	case codes.FailedPrecondition:
+		return http.StatusPreconditionFailed
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.Canceled:
+		return 499
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// chainInterceptors combines the interceptors like grpc.ChainUnaryInterceptor.
+func chainInterceptors(interceptors []grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		next := handler
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor := interceptors[i]
+			inner := next
+			next = func(ctx context.Context, req any) (any, error) {
+				return interceptor(ctx, req, info, inner)
+			}
+		}
+		return next(ctx, req)
+	}
+}
+
+// serveHTTP runs the http server on the given addr until ctx is done.
+func serveHTTP(ctx context.Context, logger *slog.Logger, handler http.Handler, addr string, tlsConfig *tls.Config) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listen on address %q: %w", addr, err)
+	}
+
+	srv := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		TLSConfig:         tlsConfig,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+
+	wait := make(chan struct{})
+	go func() {
+		<-ctx.Done()
+		if err := srv.Shutdown(context.Background()); err != nil {
+			logger.Error("shutting down http server", "error", err)
+		}
+		close(wait)
+	}()
+
+	logger.Info("running http server", "addr", addr)
+	if tlsConfig != nil {
+		err = srv.ServeTLS(lis, "", "")
+	} else {
+		err = srv.Serve(lis)
+	}
+
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("running http server: %w", err)
+	}
+
+	<-wait
+	return nil
+}
@@ -0,0 +1,144 @@
+package grpc_test
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/OpenSlides/vote-decrypt/auth"
+	"github.com/OpenSlides/vote-decrypt/crypto"
+	"github.com/OpenSlides/vote-decrypt/grpc"
+)
+
+// gatewayCall sends a request to the http gateway and decodes the json
+// response.
+func gatewayCall(t *testing.T, url string, token string, body any, resp any) int {
+	t.Helper()
+
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		t.Fatalf("encoding body: %v", err)
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewReader(encoded))
+	if err != nil {
+		t.Fatalf("creating request: %v", err)
+	}
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	r, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("sending request: %v", err)
+	}
+	defer r.Body.Close()
+
+	if resp != nil {
+		if err := json.NewDecoder(r.Body).Decode(resp); err != nil {
+			t.Fatalf("decoding response: %v", err)
+		}
+	}
+
+	return r.StatusCode
+}
+
+func TestGateway(t *testing.T) {
+	tokens, err := auth.ParseTokens(strings.NewReader("instance1 token1"))
+	if err != nil {
+		t.Fatalf("parsing tokens: %v", err)
+	}
+
+	httpAddr := freeAddr(t)
+	runServer(t, grpc.WithTokens(tokens), grpc.WithInsecureTokens(), grpc.WithHTTPAddr(httpAddr))
+	waitForServer(httpAddr)
+	url := "http://" + httpAddr
+
+	t.Run("Start and Stop", func(t *testing.T) {
+		var start struct {
+			PubKey []byte `json:"pubKey"`
+			PubSig []byte `json:"pubSig"`
+		}
+		code := gatewayCall(t, url+"/Decrypt/Start", "token1", map[string]any{"id": "instance1/1"}, &start)
+		if code != http.StatusOK {
+			t.Fatalf("Start returned status %d", code)
+		}
+
+		vote, err := crypto.Encrypt(rand.Reader, ecdh.X25519(), start.PubKey, []byte(`"Y"`))
+		if err != nil {
+			t.Fatalf("encrypt: %v", err)
+		}
+
+		var stop struct {
+			Votes     []byte `json:"votes"`
+			Signature []byte `json:"signature"`
+		}
+		code = gatewayCall(t, url+"/Decrypt/Stop", "token1", map[string]any{"id": "instance1/1", "votes": [][]byte{vote}}, &stop)
+		if code != http.StatusOK {
+			t.Fatalf("Stop returned status %d", code)
+		}
+
+		expect := `{"id":"instance1/1","votes":["Y"]}`
+		if string(stop.Votes) != expect {
+			t.Errorf("got votes %s, expected %s", stop.Votes, expect)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for _, tt := range []struct {
+			name   string
+			path   string
+			token  string
+			body   any
+			expect int
+		}{
+			{"no token", "/Decrypt/Start", "", map[string]any{"id": "instance1/2"}, http.StatusUnauthorized},
+			{"wrong tenant", "/Decrypt/Start", "token1", map[string]any{"id": "other/2"}, http.StatusForbidden},
+			{"unknown poll", "/Decrypt/Stop", "token1", map[string]any{"id": "instance1/unknown"}, http.StatusNotFound},
+			{"invalid body", "/Decrypt/Start", "token1", map[string]any{"id": 5}, http.StatusBadRequest},
+			{"unknown method", "/Decrypt/GetSecret", "token1", nil, http.StatusNotFound},
+		} {
+			t.Run(tt.name, func(t *testing.T) {
+				var resp struct {
+					Code string `json:"code"`
+				}
+				code := gatewayCall(t, url+tt.path, tt.token, tt.body, &resp)
+				if code != tt.expect {
+					t.Errorf("got status %d (%s), expected %d", code, resp.Code, tt.expect)
+				}
+			})
+		}
+	})
+
+	t.Run("openapi", func(t *testing.T) {
+		resp, err := http.Get(url + "/openapi.json")
+		if err != nil {
+			t.Fatalf("get openapi: %v", err)
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("reading body: %v", err)
+		}
+
+		var spec struct {
+			Paths map[string]any `json:"paths"`
+		}
+		if err := json.Unmarshal(body, &spec); err != nil {
+			t.Fatalf("decoding spec: %v", err)
+		}
+
+		for _, method := range []string{"PublicMainKey", "Start", "Stop", "Clear"} {
+			if _, ok := spec.Paths["/Decrypt/"+method]; !ok {
+				t.Errorf("spec does not contain method %s", method)
+			}
+		}
+	})
+}
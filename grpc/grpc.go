@@ -5,34 +5,165 @@ package grpc
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net"
+	"time"
 
+	"github.com/OpenSlides/vote-decrypt/auth"
 	"github.com/OpenSlides/vote-decrypt/decrypt"
+	"github.com/OpenSlides/vote-decrypt/errorcode"
+	"github.com/OpenSlides/vote-decrypt/logging"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/status"
 )
 
+// defaultMaxMessageSize is the default of the grpc server.
+const defaultMaxMessageSize = 4 << 20
+
 // RunServer runs a grpc server on the given addr until ctx is done.
-func RunServer(ctx context.Context, decrypt *decrypt.Decrypt, addr string) error {
+//
+// The server provides the Decrypt service and the Admin service. The Admin
+// service can be moved to another address with WithAdminAddr(). On the same
+// address, it is only provided, if tokens or client certificates are
+// configured. Otherwise, every caller could use it.
+//
+// If tokens or client certificates are configured, all callers have to
+// authenticate. See the package auth. Tokens require tls, unless
+// WithInsecureTokens() is used.
+//
+// Both servers provide the grpc.health.v1 health service, that reports the
+// status of decrypt.Health(). It does not require authentication.
+//
+// With WithHTTPAddr(), the methods of the Decrypt service are also provided as
+// JSON over http. See the file openapi.json.
+//
+// The server creates spans with the global tracer provider and continues
+// traces from the clients.
+func RunServer(ctx context.Context, decrypt *decrypt.Decrypt, addr string, options ...ServerOption) error {
+	cfg := serverConfig{
+		logger:         slog.Default(),
+		healthInterval: 5 * time.Second,
+		maxMessageSize: defaultMaxMessageSize,
+	}
+	for _, o := range options {
+		o(&cfg)
+	}
+
+	if !cfg.tokens.Empty() && cfg.tls == nil && !cfg.insecureTokens {
+		return errors.New("tokens would be sent in cleartext without tls, use tls or allow insecure tokens")
+	}
+
+	serverOptions := []grpc.ServerOption{
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.MaxRecvMsgSize(cfg.maxMessageSize),
+	}
+	if cfg.tls != nil {
+		serverOptions = append(serverOptions, grpc.Creds(credentials.NewTLS(cfg.tls)))
+	}
+
+	interceptors := []grpc.UnaryServerInterceptor{metricsInterceptor}
+
+	certAuth := cfg.tls != nil && cfg.tls.ClientCAs != nil
+	withAuth := !cfg.tokens.Empty() || certAuth
+	if withAuth {
+		interceptors = append(interceptors, authInterceptor(cfg.tokens, certAuth))
+	}
+
+	if cfg.rateLimit > 0 {
+		interceptors = append(interceptors, newRateLimiter(cfg.rateLimit, cfg.rateBurst).interceptor())
+	}
+	serverOptions = append(serverOptions, grpc.ChainUnaryInterceptor(interceptors...))
+
+	server := grpcServer{decrypt: decrypt, logger: cfg.logger}
+
+	registrar := grpc.NewServer(serverOptions...)
+	RegisterDecryptServer(registrar, server)
+	registerHealth(registrar, decrypt, cfg)
+
+	servers := []namedServer{
+		{"", func(ctx context.Context) error { return serve(ctx, cfg.logger, registrar, addr) }},
+	}
+
+	switch {
+	case cfg.adminAddr == "" && withAuth:
+		RegisterAdminServer(registrar, adminServer{decrypt: decrypt, logger: cfg.logger})
+
+	case cfg.adminAddr == "":
+		cfg.logger.Warn("admin service is disabled, since the server has no authentication and no admin address")
+
+	default:
+		adminRegistrar := grpc.NewServer(serverOptions...)
+		RegisterAdminServer(adminRegistrar, adminServer{decrypt: decrypt, logger: cfg.logger})
+		registerHealth(adminRegistrar, decrypt, cfg)
+
+		servers = append(servers, namedServer{"admin server", func(ctx context.Context) error {
+			return serve(ctx, cfg.logger, adminRegistrar, cfg.adminAddr)
+		}})
+	}
+
+	if cfg.httpAddr != "" {
+		handler := newGateway(server, interceptors, cfg.maxMessageSize)
+		servers = append(servers, namedServer{"http gateway", func(ctx context.Context) error {
+			return serveHTTP(ctx, cfg.logger, handler, cfg.httpAddr, cfg.tls)
+		}})
+	}
+
+	return runServers(ctx, servers)
+}
+
+// namedServer is a server, that runs until the context is done. The name is
+// used for error messages.
+type namedServer struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+// runServers runs all servers. If one server stops, all other servers are
+// stopped.
+func runServers(ctx context.Context, servers []namedServer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make(chan error, len(servers))
+	for _, s := range servers {
+		go func() {
+			defer cancel()
+			err := s.run(ctx)
+			if err != nil && s.name != "" {
+				err = fmt.Errorf("%s: %w", s.name, err)
+			}
+			errs <- err
+		}()
+	}
+
+	var all []error
+	for range servers {
+		all = append(all, <-errs)
+	}
+	return errors.Join(all...)
+}
+
+// serve runs the grpc server on the given addr until ctx is done.
+func serve(ctx context.Context, logger *slog.Logger, registrar *grpc.Server, addr string) error {
 	lis, err := net.Listen("tcp", addr)
 	if err != nil {
 		return fmt.Errorf("listen on address %q: %w", addr, err)
 	}
 
-	registrar := grpc.NewServer()
-	RegisterDecryptServer(registrar, grpcServer{decrypt})
-
 	wait := make(chan struct{})
 	go func() {
 		<-ctx.Done()
 		registrar.GracefulStop()
-		wait <- struct{}{}
+		close(wait)
 	}()
 
-	log.Printf("Running grpc server on %s\n", addr)
+	logger.Info("running grpc server", "addr", addr)
 	if err := registrar.Serve(lis); err != nil {
 		return fmt.Errorf("running grpc server: %w", err)
 	}
@@ -47,23 +178,64 @@ func RunServer(ctx context.Context, decrypt *decrypt.Decrypt, addr string) error
 // This is not needed vote vote-decrypt but is used by the vote-service.
 type Client struct {
 	decryptClient DecryptClient
+	tenant        string
+	priority      int32
 }
 
 // NewClient creates a connection to a decrypt grpc server and wrapps then
 // into a decrypt.crypto interface.
-func NewClient(addr string) (*Client, func() error, error) {
-	// TODO: use secure connection
-	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+//
+// As default, the connection does not use tls. Use WithClientTLS() for a
+// secure connection.
+//
+// The client creates spans with the global tracer provider and sends the trace
+// context to the server.
+func NewClient(addr string, options ...ClientOption) (*Client, func() error, error) {
+	conn, err := dial(addr, options...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var cfg clientConfig
+	for _, o := range options {
+		o(&cfg)
+	}
+
+	return &Client{decryptClient: NewDecryptClient(conn), tenant: cfg.tenant, priority: cfg.priority}, conn.Close, nil
+}
+
+// dial creates a connection to a grpc server.
+func dial(addr string, options ...ClientOption) (*grpc.ClientConn, error) {
+	var cfg clientConfig
+	for _, o := range options {
+		o(&cfg)
+	}
+
+	transportCredentials := insecure.NewCredentials()
+	if cfg.tls != nil {
+		transportCredentials = credentials.NewTLS(cfg.tls)
+	}
+
+	dialOptions := []grpc.DialOption{
+		grpc.WithTransportCredentials(transportCredentials),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+	}
+
+	if cfg.token != "" {
+		dialOptions = append(dialOptions, grpc.WithPerRPCCredentials(tokenCredentials{token: cfg.token, insecure: cfg.insecureToken}))
+	}
+
+	conn, err := grpc.Dial(addr, dialOptions...)
 	if err != nil {
-		return nil, nil, fmt.Errorf("creating connection to decrypt service: %w", err)
+		return nil, fmt.Errorf("creating connection to decrypt service: %w", err)
 	}
 
-	return &Client{decryptClient: NewDecryptClient(conn)}, conn.Close, nil
+	return conn, nil
 }
 
 // PublicMainKey calls the grpc method.
 func (c *Client) PublicMainKey(ctx context.Context) ([]byte, error) {
-	resp, err := c.decryptClient.PublicMainKey(ctx, &EmptyMessage{})
+	resp, err := c.decryptClient.PublicMainKey(ctx, &PublicMainKeyRequest{Tenant: c.tenant})
 	if err != nil {
 		return nil, fmt.Errorf("sending grpc request: %w", err)
 	}
@@ -73,7 +245,14 @@ func (c *Client) PublicMainKey(ctx context.Context) ([]byte, error) {
 
 // Start calls the Start grpc message.
 func (c *Client) Start(ctx context.Context, pollID string) (pubKey []byte, pubKeySig []byte, err error) {
-	resp, err := c.decryptClient.Start(ctx, &StartRequest{Id: pollID})
+	return c.StartWithTTL(ctx, pollID, 0)
+}
+
+// StartWithTTL calls the Start grpc message with a time to live for the poll.
+//
+// The ttl is send in seconds.
+func (c *Client) StartWithTTL(ctx context.Context, pollID string, ttl time.Duration) (pubKey []byte, pubKeySig []byte, err error) {
+	resp, err := c.decryptClient.Start(ctx, &StartRequest{Id: pollID, Ttl: uint32(ttl / time.Second), Tenant: c.tenant})
 	if err != nil {
 		return nil, nil, fmt.Errorf("sending grpc message: %w", err)
 	}
@@ -83,16 +262,29 @@ func (c *Client) Start(ctx context.Context, pollID string) (pubKey []byte, pubKe
 
 // Stop calls the Stop grpc message.
 func (c *Client) Stop(ctx context.Context, pollID string, voteList [][]byte) (decryptedContent, signature []byte, err error) {
-	resp, err := c.decryptClient.Stop(ctx, &StopRequest{Id: pollID, Votes: voteList})
+	decryptedContent, signature, _, err = c.StopWithContribution(ctx, pollID, voteList, nil)
+	return decryptedContent, signature, err
+}
+
+// StopWithContribution calls the Stop grpc message with a public randomness
+// contribution for the commit-reveal mode. It returns the revealed seed.
+func (c *Client) StopWithContribution(ctx context.Context, pollID string, voteList [][]byte, contribution []byte) (decryptedContent, signature, seed []byte, err error) {
+	resp, err := c.decryptClient.Stop(ctx, &StopRequest{
+		Id:           pollID,
+		Votes:        voteList,
+		Tenant:       c.tenant,
+		Contribution: contribution,
+		Priority:     c.priority,
+	})
 	if err != nil {
-		return nil, nil, fmt.Errorf("sending grpc message: %w", err)
+		return nil, nil, nil, fmt.Errorf("sending grpc message: %w", err)
 	}
-	return resp.Votes, resp.Signature, nil
+	return resp.Votes, resp.Signature, resp.Seed, nil
 }
 
 // Clear calls the Clear grpc message.
 func (c *Client) Clear(ctx context.Context, pollID string) error {
-	_, err := c.decryptClient.Clear(ctx, &ClearRequest{Id: pollID})
+	_, err := c.decryptClient.Clear(ctx, &ClearRequest{Id: pollID, Tenant: c.tenant})
 	if err != nil {
 		return fmt.Errorf("sending grpc message: %w", err)
 	}
@@ -100,60 +292,189 @@ func (c *Client) Clear(ctx context.Context, pollID string) error {
 	return nil
 }
 
+// GetPoll calls the GetPoll grpc message.
+func (c *Client) GetPoll(ctx context.Context, pollID string) (decrypt.Poll, error) {
+	resp, err := c.decryptClient.GetPoll(ctx, &GetPollRequest{Id: pollID, Tenant: c.tenant})
+	if err != nil {
+		return decrypt.Poll{}, fmt.Errorf("sending grpc message: %w", err)
+	}
+
+	return pollFromResponse(resp), nil
+}
+
 type grpcServer struct {
 	decrypt *decrypt.Decrypt
+	logger  *slog.Logger
 }
 
 // grpcError converts an error to a grpc error.
-func (s grpcServer) grpcError(err error) error {
-	// TODO: Set the logger on initialization.
-	log.Printf("GRPC: %v", err)
+//
+// Errors with an errorcode are returned with the matching grpc code. All other
+// errors are internal.
+//
+// The error is logged only with its class. The error message is not logged,
+// since it could contain details about the keys or votes.
+func grpcError(ctx context.Context, logger *slog.Logger, err error) error {
+	logger.WarnContext(ctx, "request failed", "error_class", logging.ErrorClass(err))
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, "timeout reached")
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, "request canceled")
+	}
+
+	var errCode errorcode.DecryptError
+	if !errors.As(err, &errCode) {
+		return status.Error(codes.Internal, "Ups, someting went wrong!")
+	}
+
+	code := codes.Internal
+	switch errCode {
+	case errorcode.Exist:
+		code = codes.AlreadyExists
+	case errorcode.NotExist:
+		code = codes.NotFound
+	case errorcode.Invalid:
+		code = codes.InvalidArgument
+	case errorcode.WrongState:
+		code = codes.FailedPrecondition
+	case errorcode.Exhausted:
+		code = codes.ResourceExhausted
+	}
 
-	// Currently, all errors are internal
-	return status.Error(codes.Internal, "Ups, someting went wrong!")
+	return status.Error(code, errCode.Error())
 }
 
 func (s grpcServer) Start(ctx context.Context, req *StartRequest) (*StartResponse, error) {
-	log.Printf("Start request for id %s", req.Id)
-	pubKey, pubKeySig, err := s.decrypt.Start(ctx, req.Id)
+	s.logger.InfoContext(ctx, "start request", "poll_id", req.Id)
+	ctx, err := tenantContext(ctx, req.Tenant)
 	if err != nil {
-		return nil, s.grpcError(fmt.Errorf("starting vote: %w", err))
+		return nil, err
+	}
+
+	ttl := time.Duration(req.Ttl) * time.Second
+	pubKey, pubKeySig, err := s.decrypt.StartWithTTL(ctx, req.Id, ttl)
+	if err != nil {
+		return nil, grpcError(ctx, s.logger, fmt.Errorf("starting vote: %w", err))
+	}
+
+	commitment, commitmentSig, err := s.decrypt.SeedCommitment(ctx, req.Id)
+	if err != nil {
+		return nil, grpcError(ctx, s.logger, fmt.Errorf("getting seed commitment: %w", err))
 	}
 
 	return &StartResponse{
-		PubKey: pubKey,
-		PubSig: pubKeySig,
+		PubKey:            pubKey,
+		PubSig:            pubKeySig,
+		SeedCommitment:    commitment,
+		SeedCommitmentSig: commitmentSig,
 	}, nil
 }
 
 func (s grpcServer) Stop(ctx context.Context, req *StopRequest) (*StopResponse, error) {
-	log.Printf("Stop request for id %s", req.Id)
-	decrypted, signature, err := s.decrypt.Stop(ctx, req.Id, req.Votes)
+	s.logger.InfoContext(ctx, "stop request", "poll_id", req.Id, "votes", len(req.Votes))
+	ctx, err := tenantContext(ctx, req.Tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only admins can choose the priority. Otherwise a tenant could get a
+	// bigger share of the workers then the others.
+	if caller, _ := auth.FromContext(ctx); caller == auth.Admin {
+		ctx = decrypt.WithPriority(ctx, int(req.Priority))
+	}
+	decrypted, signature, seed, err := s.decrypt.StopWithContribution(ctx, req.Id, req.Votes, req.Contribution)
 	if err != nil {
-		return nil, s.grpcError(fmt.Errorf("stopping vote: %w", err))
+		return nil, grpcError(ctx, s.logger, fmt.Errorf("stopping vote: %w", err))
 	}
 
 	return &StopResponse{
 		Votes:     decrypted,
 		Signature: signature,
+		Seed:      seed,
 	}, nil
 }
 
 func (s grpcServer) Clear(ctx context.Context, req *ClearRequest) (*EmptyMessage, error) {
-	log.Printf("Stop request for id %s", req.Id)
-	err := s.decrypt.Clear(ctx, req.Id)
+	s.logger.InfoContext(ctx, "clear request", "poll_id", req.Id)
+	ctx, err := tenantContext(ctx, req.Tenant)
 	if err != nil {
-		return nil, s.grpcError(fmt.Errorf("clearing vote: %w", err))
+		return nil, err
+	}
+
+	if err := s.decrypt.Clear(ctx, req.Id); err != nil {
+		return nil, grpcError(ctx, s.logger, fmt.Errorf("clearing vote: %w", err))
 	}
 
 	return new(EmptyMessage), nil
 }
 
-func (s grpcServer) PublicMainKey(ctx context.Context, req *EmptyMessage) (*PublicMainKeyResponse, error) {
-	log.Printf("Public Poll Key request")
+func (s grpcServer) GetPoll(ctx context.Context, req *GetPollRequest) (*GetPollResponse, error) {
+	ctx, err := tenantContext(ctx, req.Tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	poll, err := s.decrypt.GetPoll(ctx, req.Id)
+	if err != nil {
+		return nil, grpcError(ctx, s.logger, fmt.Errorf("getting poll: %w", err))
+	}
+
+	return pollToResponse(poll), nil
+}
+
+func (s grpcServer) PublicMainKey(ctx context.Context, req *PublicMainKeyRequest) (*PublicMainKeyResponse, error) {
+	s.logger.DebugContext(ctx, "public main key request")
+	ctx, err := tenantContext(ctx, req.Tenant)
+	if err != nil {
+		return nil, err
+	}
+
 	key := s.decrypt.PublicMainKey(ctx)
 
 	return &PublicMainKeyResponse{
 		PublicKey: key,
 	}, nil
 }
+
+func pollToResponse(poll decrypt.Poll) *GetPollResponse {
+	return &GetPollResponse{
+		Id:      poll.ID,
+		State:   PollState(poll.State),
+		Created: unixTime(poll.Created),
+		Started: unixTime(poll.Started),
+		Stopped: unixTime(poll.Stopped),
+		Cleared: unixTime(poll.Cleared),
+		Expired: unixTime(poll.Expired),
+		Expires: unixTime(poll.Expires),
+	}
+}
+
+func pollFromResponse(resp *GetPollResponse) decrypt.Poll {
+	return decrypt.Poll{
+		ID:      resp.Id,
+		State:   decrypt.PollState(resp.State),
+		Created: fromUnixTime(resp.Created),
+		Started: fromUnixTime(resp.Started),
+		Stopped: fromUnixTime(resp.Stopped),
+		Cleared: fromUnixTime(resp.Cleared),
+		Expired: fromUnixTime(resp.Expired),
+		Expires: fromUnixTime(resp.Expires),
+	}
+}
+
+// unixTime converts a time to unix seconds. The zero time is converted to 0.
+func unixTime(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+func fromUnixTime(sec int64) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0)
+}
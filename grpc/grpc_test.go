@@ -0,0 +1,263 @@
+package grpc_test
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OpenSlides/vote-decrypt/crypto"
+	"github.com/OpenSlides/vote-decrypt/decrypt"
+	"github.com/OpenSlides/vote-decrypt/grpc"
+	"github.com/OpenSlides/vote-decrypt/store"
+	ggrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// freeAddr returns a local address with a free port.
+func freeAddr(t *testing.T) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("finding free port: %v", err)
+	}
+	defer lis.Close()
+	return lis.Addr().String()
+}
+
+// runServer starts a server in the background and returns its address.
+func runServer(t *testing.T, options ...grpc.ServerOption) (*decrypt.Decrypt, string) {
+	t.Helper()
+
+	d := decrypt.New(
+		crypto.New(make([]byte, 32), rand.Reader, nil),
+		store.New(t.TempDir()),
+	)
+
+	return d, runDecryptServer(t, d, options...)
+}
+
+// runDecryptServer starts a server for the given decrypt component in the
+// background and returns its address.
+func runDecryptServer(t *testing.T, d *decrypt.Decrypt, options ...grpc.ServerOption) string {
+	t.Helper()
+
+	addr := freeAddr(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- grpc.RunServer(ctx, d, addr, options...)
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("RunServer: %v", err)
+		}
+	})
+
+	waitForServer(addr)
+	return addr
+}
+
+// waitForServer waits until a server listens on the address.
+func waitForServer(addr string) {
+	for i := 0; i < 100; i++ {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			conn.Close()
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestClient(t *testing.T) {
+	_, addr := runServer(t)
+	ctx := context.Background()
+
+	client, close, err := grpc.NewClient(addr)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	defer close()
+
+	pubKey, pubKeySig, err := client.Start(ctx, "test/1")
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	mainKey, err := client.PublicMainKey(ctx)
+	if err != nil {
+		t.Fatalf("PublicMainKey: %v", err)
+	}
+
+	if !crypto.Verify(mainKey, pubKey, pubKeySig) {
+		t.Errorf("invalid signature for public poll key")
+	}
+
+	if _, _, err := client.Stop(ctx, "test/1", nil); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+
+	poll, err := client.GetPoll(ctx, "test/1")
+	if err != nil {
+		t.Fatalf("GetPoll: %v", err)
+	}
+
+	if poll.State != decrypt.StateStopped || poll.Stopped.IsZero() {
+		t.Errorf("got poll %v, expected a stopped poll", poll)
+	}
+
+	_, _, err = client.Start(ctx, "test/1")
+	if got := status.Code(errors.Unwrap(err)); got != codes.FailedPrecondition {
+		t.Errorf("Start after stop returned code %s, expected %s", got, codes.FailedPrecondition)
+	}
+
+	_, err = client.GetPoll(ctx, "unknown")
+	if got := status.Code(errors.Unwrap(err)); got != codes.NotFound {
+		t.Errorf("GetPoll for unknown poll returned code %s, expected %s", got, codes.NotFound)
+	}
+}
+
+func TestCommitReveal(t *testing.T) {
+	d := decrypt.New(
+		crypto.New(make([]byte, 32), rand.Reader, nil),
+		store.New(t.TempDir()),
+		decrypt.WithCommitReveal(),
+	)
+	addr := runDecryptServer(t, d)
+	ctx := context.Background()
+
+	conn, err := ggrpc.Dial(addr, ggrpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("connecting to server: %v", err)
+	}
+	defer conn.Close()
+	client := grpc.NewDecryptClient(conn)
+
+	startResp, err := client.Start(ctx, &grpc.StartRequest{Id: "test/1"})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	message := decrypt.CommitmentMessage("test/1", startResp.SeedCommitment)
+	if !crypto.Verify(d.PublicMainKey(ctx), message, startResp.SeedCommitmentSig) {
+		t.Errorf("invalid signature for seed commitment")
+	}
+
+	stopResp, err := client.Stop(ctx, &grpc.StopRequest{Id: "test/1", Contribution: []byte("beacon")})
+	if err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+
+	if !decrypt.VerifySeed(stopResp.Seed, startResp.SeedCommitment) {
+		t.Errorf("revealed seed does not match the commitment")
+	}
+}
+
+func TestAdmin(t *testing.T) {
+	adminAddr := freeAddr(t)
+	d, _ := runServer(t, grpc.WithAdminAddr(adminAddr))
+	ctx := context.Background()
+
+	if _, _, err := d.Start(ctx, "test/1"); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	conn, err := ggrpc.Dial(adminAddr, ggrpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("connecting to admin service: %v", err)
+	}
+	defer conn.Close()
+	admin := grpc.NewAdminClient(conn)
+
+	health, err := admin.Health(ctx, &grpc.EmptyMessage{})
+	if err != nil {
+		t.Fatalf("Health: %v", err)
+	}
+
+	if !health.Healthy {
+		t.Errorf("service is not healthy: %s", health.Error)
+	}
+
+	polls, err := admin.ListPolls(ctx, &grpc.EmptyMessage{})
+	if err != nil {
+		t.Fatalf("ListPolls: %v", err)
+	}
+
+	if len(polls.Polls) != 1 || polls.Polls[0].Id != "test/1" || polls.Polls[0].State != grpc.PollState_POLL_STATE_STARTED {
+		t.Errorf("ListPolls returned %v, expected one started poll", polls.Polls)
+	}
+
+	if _, err := admin.ForceClear(ctx, &grpc.ClearRequest{Id: "test/1"}); err != nil {
+		t.Fatalf("ForceClear: %v", err)
+	}
+
+	poll, err := admin.GetPoll(ctx, &grpc.GetPollRequest{Id: "test/1"})
+	if err != nil {
+		t.Fatalf("GetPoll: %v", err)
+	}
+
+	if poll.State != grpc.PollState_POLL_STATE_CLEARED {
+		t.Errorf("poll has state %s after ForceClear, expected cleared", poll.State)
+	}
+}
+
+func TestAdminWithoutAuth(t *testing.T) {
+	_, addr := runServer(t)
+
+	conn, err := ggrpc.Dial(addr, ggrpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("connecting to server: %v", err)
+	}
+	defer conn.Close()
+
+	_, err = grpc.NewAdminClient(conn).ForceClear(context.Background(), &grpc.ClearRequest{Id: "test/1"})
+	if got := status.Code(err); got != codes.Unimplemented {
+		t.Errorf("ForceClear without authentication returned code %s, expected %s", got, codes.Unimplemented)
+	}
+}
+
+// unhealthyStore is a store with a health error, that contains a secret path.
+type unhealthyStore struct {
+	*store.Store
+}
+
+func (unhealthyStore) Health() error {
+	return errors.New("can not open /secret/path")
+}
+
+func TestAdminHealthError(t *testing.T) {
+	adminAddr := freeAddr(t)
+	d := decrypt.New(
+		crypto.New(make([]byte, 32), rand.Reader, nil),
+		unhealthyStore{store.New(t.TempDir())},
+	)
+	runDecryptServer(t, d, grpc.WithAdminAddr(adminAddr))
+
+	conn, err := ggrpc.Dial(adminAddr, ggrpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("connecting to admin service: %v", err)
+	}
+	defer conn.Close()
+
+	health, err := grpc.NewAdminClient(conn).Health(context.Background(), &grpc.EmptyMessage{})
+	if err != nil {
+		t.Fatalf("Health: %v", err)
+	}
+
+	if health.Healthy {
+		t.Errorf("service with a broken store is healthy")
+	}
+
+	if strings.Contains(health.Error, "/secret/path") {
+		t.Errorf("health response contains the store error: %s", health.Error)
+	}
+}
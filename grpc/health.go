@@ -0,0 +1,120 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/OpenSlides/vote-decrypt/decrypt"
+	"github.com/OpenSlides/vote-decrypt/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// healthServices are the service names, that are known by the health service.
+// The empty name is the health of the whole server.
+var healthServices = map[string]bool{
+	"":        true,
+	"Decrypt": true,
+	"Admin":   true,
+}
+
+// healthServer implements the grpc.health.v1 health service.
+//
+// The status is calculated on each request with decrypt.Health().
+type healthServer struct {
+	healthpb.UnimplementedHealthServer
+
+	decrypt  *decrypt.Decrypt
+	logger   *slog.Logger
+	interval time.Duration // interval to check the status in Watch.
+}
+
+func (s healthServer) status(ctx context.Context) healthpb.HealthCheckResponse_ServingStatus {
+	if err := s.decrypt.Health(ctx); err != nil {
+		s.logger.WarnContext(ctx, "health check failed", "error_class", logging.ErrorClass(err))
+		return healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	return healthpb.HealthCheckResponse_SERVING
+}
+
+func (s healthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	if !healthServices[req.Service] {
+		return nil, status.Errorf(codes.NotFound, "unknown service %q", req.Service)
+	}
+
+	return &healthpb.HealthCheckResponse{Status: s.status(ctx)}, nil
+}
+
+func (s healthServer) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	ctx := stream.Context()
+
+	if !healthServices[req.Service] {
+		// The spec requires to keep the stream open for unknown services.
+		if err := stream.Send(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVICE_UNKNOWN}); err != nil {
+			return err
+		}
+		<-ctx.Done()
+		return nil
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	last := healthpb.HealthCheckResponse_UNKNOWN
+	for {
+		current := s.status(ctx)
+		if current != last {
+			if err := stream.Send(&healthpb.HealthCheckResponse{Status: current}); err != nil {
+				return err
+			}
+			last = current
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// HealthCheck calls the grpc.health.v1 Check method of the server on addr.
+//
+// Returns an error, if the server is not serving.
+func HealthCheck(ctx context.Context, addr string, options ...ClientOption) error {
+	conn, err := dial(addr, options...)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		return fmt.Errorf("sending health check: %w", err)
+	}
+
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("server is %s", resp.Status)
+	}
+
+	return nil
+}
+
+// registerHealth registers the health service and, if enabled, the reflection
+// service.
+func registerHealth(registrar *grpc.Server, decrypt *decrypt.Decrypt, cfg serverConfig) {
+	healthpb.RegisterHealthServer(registrar, healthServer{
+		decrypt:  decrypt,
+		logger:   cfg.logger,
+		interval: cfg.healthInterval,
+	})
+
+	if cfg.reflection {
+		reflection.Register(registrar)
+	}
+}
@@ -0,0 +1,127 @@
+package grpc_test
+
+import (
+	"context"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/OpenSlides/vote-decrypt/auth"
+	"github.com/OpenSlides/vote-decrypt/crypto"
+	"github.com/OpenSlides/vote-decrypt/decrypt"
+	"github.com/OpenSlides/vote-decrypt/grpc"
+	"github.com/OpenSlides/vote-decrypt/store"
+	ggrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+)
+
+func TestHealth(t *testing.T) {
+	// The health service has to work without credentials.
+	tokens, err := auth.ParseTokens(strings.NewReader("instance1 token1"))
+	if err != nil {
+		t.Fatalf("parsing tokens: %v", err)
+	}
+	_, addr := runServer(t, grpc.WithTokens(tokens), grpc.WithInsecureTokens())
+	ctx := context.Background()
+
+	if err := grpc.HealthCheck(ctx, addr); err != nil {
+		t.Errorf("HealthCheck: %v", err)
+	}
+
+	conn, err := ggrpc.Dial(addr, ggrpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	client := healthpb.NewHealthClient(conn)
+
+	for _, service := range []string{"", "Decrypt", "Admin"} {
+		resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		if err != nil {
+			t.Fatalf("Check(%q): %v", service, err)
+		}
+
+		if resp.Status != healthpb.HealthCheckResponse_SERVING {
+			t.Errorf("Check(%q) returned %s", service, resp.Status)
+		}
+	}
+
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: "unknown"}); err == nil {
+		t.Errorf("Check for unknown service did not return an error")
+	}
+
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+
+	resp, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("receiving from watch: %v", err)
+	}
+
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("Watch returned %s", resp.Status)
+	}
+}
+
+func TestHealthNotServing(t *testing.T) {
+	// A store in a path, that is a file, is not writable.
+	storePath := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(storePath, nil, 0o600); err != nil {
+		t.Fatalf("creating file: %v", err)
+	}
+
+	d := decrypt.New(
+		crypto.New(make([]byte, 32), rand.Reader, nil),
+		store.New(storePath),
+	)
+	addr := runDecryptServer(t, d)
+
+	if err := grpc.HealthCheck(context.Background(), addr); err == nil {
+		t.Errorf("HealthCheck did not return an error")
+	}
+}
+
+func TestReflection(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		options []grpc.ServerOption
+		expect  bool
+	}{
+		{"disabled", nil, false},
+		{"enabled", []grpc.ServerOption{grpc.WithReflection()}, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, addr := runServer(t, tt.options...)
+
+			conn, err := ggrpc.Dial(addr, ggrpc.WithTransportCredentials(insecure.NewCredentials()))
+			if err != nil {
+				t.Fatalf("dial: %v", err)
+			}
+			defer conn.Close()
+
+			stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(context.Background())
+			if err != nil {
+				t.Fatalf("ServerReflectionInfo: %v", err)
+			}
+
+			req := &reflectionpb.ServerReflectionRequest{
+				MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+			}
+			if err := stream.Send(req); err != nil {
+				t.Fatalf("send: %v", err)
+			}
+
+			_, err = stream.Recv()
+			if got := err == nil; got != tt.expect {
+				t.Errorf("reflection available: %t, expected %t (err: %v)", got, tt.expect, err)
+			}
+		})
+	}
+}
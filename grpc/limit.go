@@ -0,0 +1,64 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/OpenSlides/vote-decrypt/auth"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// rateLimiter holds a token bucket for each caller.
+type rateLimiter struct {
+	limit rate.Limit
+	burst int
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+func newRateLimiter(limit rate.Limit, burst int) *rateLimiter {
+	return &rateLimiter{
+		limit:    limit,
+		burst:    burst,
+		limiters: make(map[string]*rate.Limiter),
+	}
+}
+
+// allow returns true, if the caller has a token left.
+func (l *rateLimiter) allow(caller string) bool {
+	l.mu.Lock()
+	limiter, ok := l.limiters[caller]
+	if !ok {
+		limiter = rate.NewLimiter(l.limit, l.burst)
+		l.limiters[caller] = limiter
+	}
+	l.mu.Unlock()
+
+	return limiter.Allow()
+}
+
+// interceptor returns an interceptor, that rejects requests with
+// ResourceExhausted, if the caller has no tokens left.
+//
+// The caller is the authenticated tenant. If the server does not use
+// authentication, all requests share one bucket. Health checks are not
+// limited.
+func (l *rateLimiter) interceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if strings.HasPrefix(info.FullMethod, "/grpc.health.v1.Health/") {
+			return handler(ctx, req)
+		}
+
+		caller, _ := auth.FromContext(ctx)
+		if !l.allow(caller) {
+			return nil, status.Error(codes.ResourceExhausted, "rate limit exceeded")
+		}
+
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,77 @@
+package grpc_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/OpenSlides/vote-decrypt/auth"
+	"github.com/OpenSlides/vote-decrypt/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRateLimit(t *testing.T) {
+	tokens, err := auth.ParseTokens(strings.NewReader("instance1 token1\ninstance2 token2"))
+	if err != nil {
+		t.Fatalf("parsing tokens: %v", err)
+	}
+
+	_, addr := runServer(t, grpc.WithTokens(tokens), grpc.WithInsecureTokens(), grpc.WithRateLimit(0.001, 2))
+	ctx := context.Background()
+
+	client1, close1, err := grpc.NewClient(addr, grpc.WithToken("token1"), grpc.WithInsecureToken())
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	defer close1()
+
+	for i := 0; i < 2; i++ {
+		if _, err := client1.PublicMainKey(ctx); err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+	}
+
+	_, err = client1.PublicMainKey(ctx)
+	if got := status.Code(errors.Unwrap(err)); got != codes.ResourceExhausted {
+		t.Errorf("third request returned %v, expected ResourceExhausted", err)
+	}
+
+	// Other callers have there own bucket.
+	client2, close2, err := grpc.NewClient(addr, grpc.WithToken("token2"), grpc.WithInsecureToken())
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	defer close2()
+
+	if _, err := client2.PublicMainKey(ctx); err != nil {
+		t.Errorf("request of other caller: %v", err)
+	}
+
+	// Health checks are not limited.
+	if err := grpc.HealthCheck(ctx, addr); err != nil {
+		t.Errorf("HealthCheck: %v", err)
+	}
+}
+
+func TestMaxMessageSize(t *testing.T) {
+	_, addr := runServer(t, grpc.WithMaxMessageSize(1024))
+	ctx := context.Background()
+
+	client, close, err := grpc.NewClient(addr)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	defer close()
+
+	if _, _, err := client.Start(ctx, "test/1"); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	_, _, err = client.Stop(ctx, "test/1", [][]byte{bytes.Repeat([]byte("x"), 2048)})
+	if got := status.Code(errors.Unwrap(err)); got != codes.ResourceExhausted {
+		t.Errorf("Stop returned %v, expected ResourceExhausted", err)
+	}
+}
@@ -0,0 +1,97 @@
+package grpc_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/OpenSlides/vote-decrypt/crypto"
+	"github.com/OpenSlides/vote-decrypt/grpc"
+	"github.com/OpenSlides/vote-decrypt/logging"
+)
+
+// syncBuffer is a bytes.Buffer that can be used from many goroutines.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestLoggingNoSecrets(t *testing.T) {
+	buf := new(syncBuffer)
+	logger, err := logging.New(buf, "json", "debug")
+	if err != nil {
+		t.Fatalf("creating logger: %v", err)
+	}
+
+	_, addr := runServer(t, grpc.WithLogger(logger))
+	ctx := context.Background()
+
+	client, close, err := grpc.NewClient(addr)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	defer close()
+
+	pubKey, pubKeySig, err := client.Start(ctx, "test/1")
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	secrets := [][]byte{pubKeySig}
+	var votes [][]byte
+	for _, plaintext := range []string{`"secret-plaintext-one"`, `"secret-plaintext-two"`} {
+		ciphertext, err := crypto.Encrypt(rand.Reader, ecdh.X25519(), pubKey, []byte(plaintext))
+		if err != nil {
+			t.Fatalf("encrypt: %v", err)
+		}
+		votes = append(votes, ciphertext)
+		secrets = append(secrets, []byte(plaintext), ciphertext)
+	}
+
+	decrypted, signature, err := client.Stop(ctx, "test/1", votes)
+	if err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	secrets = append(secrets, decrypted, signature)
+
+	if _, _, err := client.Stop(ctx, "test/unknown", votes); err == nil {
+		t.Fatalf("Stop on unknown poll did not return an error")
+	}
+
+	out := buf.String()
+	for _, expect := range []string{`"poll_id":"test/1"`, `"error_class":"not_exist"`} {
+		if !strings.Contains(out, expect) {
+			t.Errorf("log does not contain %s:\n%s", expect, out)
+		}
+	}
+
+	for _, secret := range secrets {
+		for _, encoded := range []string{
+			string(secret),
+			base64.StdEncoding.EncodeToString(secret),
+			hex.EncodeToString(secret),
+		} {
+			if strings.Contains(out, encoded) {
+				t.Errorf("log contains secret %q:\n%s", encoded, out)
+			}
+		}
+	}
+}
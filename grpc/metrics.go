@@ -0,0 +1,22 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/OpenSlides/vote-decrypt/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// metricsInterceptor counts the requests and observes there duration.
+func metricsInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+
+	code := status.Code(err).String()
+	metrics.RPCRequests.WithLabelValues(info.FullMethod, code).Inc()
+	metrics.RPCDuration.WithLabelValues(info.FullMethod, code).Observe(time.Since(start).Seconds())
+
+	return resp, err
+}
@@ -0,0 +1,192 @@
+package grpc
+
+import (
+	"crypto/tls"
+	"log/slog"
+	"time"
+
+	"github.com/OpenSlides/vote-decrypt/auth"
+	"golang.org/x/time/rate"
+)
+
+// ServerOption for grpc.RunServer().
+type ServerOption = func(*serverConfig)
+
+type serverConfig struct {
+	adminAddr string
+	tls       *tls.Config
+	tokens    auth.Tokens
+	logger    *slog.Logger
+	httpAddr  string
+
+	insecureTokens bool
+
+	maxMessageSize int
+	rateLimit      rate.Limit
+	rateBurst      int
+
+	reflection     bool
+	healthInterval time.Duration
+}
+
+// WithAdminAddr runs the Admin service on a separate address.
+//
+// As default, the Admin service runs on the same address as the Decrypt
+// service, but only if tokens or client certificates are configured. The
+// separate address should only be reachable by the operators, if the server
+// has no authentication.
+func WithAdminAddr(addr string) ServerOption {
+	return func(cfg *serverConfig) {
+		cfg.adminAddr = addr
+	}
+}
+
+// WithHTTPAddr runs a http gateway on the address, that provides the methods
+// of the Decrypt service as JSON.
+//
+// The gateway uses the same authentication as the grpc server. The OpenAPI
+// description is served on the path /openapi.json.
+func WithHTTPAddr(addr string) ServerOption {
+	return func(cfg *serverConfig) {
+		cfg.httpAddr = addr
+	}
+}
+
+// WithMaxMessageSize sets the maximum size of a request in bytes for the grpc
+// server and the http gateway. Default is 4 MiB.
+func WithMaxMessageSize(size int) ServerOption {
+	return func(cfg *serverConfig) {
+		cfg.maxMessageSize = size
+	}
+}
+
+// WithRateLimit limits the requests per second for each authenticated caller
+// with a token bucket. burst is the size of the bucket.
+//
+// Requests over the limit are rejected with ResourceExhausted.
+func WithRateLimit(perSecond float64, burst int) ServerOption {
+	return func(cfg *serverConfig) {
+		cfg.rateLimit = rate.Limit(perSecond)
+		cfg.rateBurst = burst
+	}
+}
+
+// WithLogger sets the logger of the server. Uses slog.Default() as default.
+//
+// Requests are logged with the poll id. Errors are only logged with their
+// class.
+func WithLogger(logger *slog.Logger) ServerOption {
+	return func(cfg *serverConfig) {
+		cfg.logger = logger
+	}
+}
+
+// WithReflection registers the grpc reflection service. It can be used to
+// debug the server with tools like grpcurl.
+func WithReflection() ServerOption {
+	return func(cfg *serverConfig) {
+		cfg.reflection = true
+	}
+}
+
+// WithHealthInterval sets the interval in which the Watch method of the health
+// service checks the status. Default is 5 seconds. Non-positive values are
+// ignored.
+func WithHealthInterval(interval time.Duration) ServerOption {
+	return func(cfg *serverConfig) {
+		if interval > 0 {
+			cfg.healthInterval = interval
+		}
+	}
+}
+
+// WithTLS uses tls for all connections.
+//
+// If the config contains ClientCAs, the common name of verified client
+// certificates is used as tenant.
+func WithTLS(config *tls.Config) ServerOption {
+	return func(cfg *serverConfig) {
+		cfg.tls = config
+	}
+}
+
+// WithTokens sets the api tokens for the callers.
+//
+// If tokens or client certificates are configured, each request has to be
+// authenticated.
+//
+// Tokens can only be used with WithTLS(), since they would be sent in
+// cleartext. See WithInsecureTokens().
+func WithTokens(tokens auth.Tokens) ServerOption {
+	return func(cfg *serverConfig) {
+		cfg.tokens = tokens
+	}
+}
+
+// WithInsecureTokens allows tokens without tls.
+//
+// The tokens are sent in cleartext. Only use it in a trusted network.
+func WithInsecureTokens() ServerOption {
+	return func(cfg *serverConfig) {
+		cfg.insecureTokens = true
+	}
+}
+
+// ClientOption for grpc.NewClient().
+type ClientOption = func(*clientConfig)
+
+type clientConfig struct {
+	tls           *tls.Config
+	token         string
+	insecureToken bool
+	tenant        string
+	priority      int32
+}
+
+// WithClientTLS uses tls to connect to the server.
+//
+// The config can contain a client certificate.
+func WithClientTLS(config *tls.Config) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.tls = config
+	}
+}
+
+// WithToken sends the api token with each request.
+//
+// The token is only sent over tls. See WithClientTLS() and
+// WithInsecureToken().
+func WithToken(token string) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.token = token
+	}
+}
+
+// WithInsecureToken sends the api token also over connections without tls.
+//
+// The token is sent in cleartext. Only use it in a trusted network.
+func WithInsecureToken() ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.insecureToken = true
+	}
+}
+
+// WithTenant sends the tenant with each request.
+//
+// Is not needed, if the client authenticates as the tenant.
+func WithTenant(tenant string) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.tenant = tenant
+	}
+}
+
+// WithPriority sends the priority with each Stop request. Polls with a higher
+// priority get a bigger share of the decrypt workers. See
+// decrypt.WithPriority().
+//
+// The server only uses the priority, if the client authenticates as admin.
+func WithPriority(priority int32) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.priority = priority
+	}
+}
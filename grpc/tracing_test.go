@@ -0,0 +1,54 @@
+package grpc_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/OpenSlides/vote-decrypt/grpc"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracePropagation(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	oldProvider := otel.GetTracerProvider()
+	oldPropagator := otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(oldProvider)
+		otel.SetTextMapPropagator(oldPropagator)
+	})
+
+	_, addr := runServer(t)
+
+	client, close, err := grpc.NewClient(addr)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	defer close()
+
+	ctx, span := provider.Tracer("test").Start(context.Background(), "vote service")
+	if _, _, err := client.Start(ctx, "test/1"); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	span.End()
+
+	var found bool
+	for _, s := range exporter.GetSpans() {
+		if s.Name == "decrypt.Start" {
+			found = true
+			if s.SpanContext.TraceID() != span.SpanContext().TraceID() {
+				t.Errorf("server span has trace id %s, expected %s", s.SpanContext.TraceID(), span.SpanContext().TraceID())
+			}
+		}
+	}
+
+	if !found {
+		t.Errorf("no span decrypt.Start was created")
+	}
+}
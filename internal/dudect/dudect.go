@@ -0,0 +1,76 @@
+// Package dudect detects timing leaks with the method of dudect.
+//
+// A function is measured many times with inputs of two classes. The classes
+// are chosen at random for each measurement. Welch's t-test tells, if the
+// durations of the two classes differ. See "dude, is my code constant time?"
+// by Reparaz, Balasch and Verbauwhede.
+package dudect
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// Threshold is the value of t, above which the durations differ for sure.
+//
+// dudect uses 10 as threshold for a leak and 4.5 as threshold for a probable
+// leak.
+const Threshold = 10
+
+// Measure calls prepare for the given number of samples with the class 0 or
+// 1. The function, that prepare returns, is measured.
+//
+// It returns the t statistic of the durations. The slowest ten percent of each
+// class are dropped, since they are mostly caused by the scheduler or the
+// garbage collector.
+func Measure(samples int, prepare func(class int) func()) float64 {
+	durations := [2][]float64{}
+	for i := 0; i < samples; i++ {
+		class := rand.Intn(2)
+		f := prepare(class)
+
+		start := time.Now()
+		f()
+		durations[class] = append(durations[class], float64(time.Since(start)))
+	}
+
+	return welch(crop(durations[0]), crop(durations[1]))
+}
+
+// crop removes the slowest ten percent of the values.
+func crop(values []float64) []float64 {
+	sort.Float64s(values)
+	return values[:len(values)*9/10]
+}
+
+// welch returns the t statistic of Welch's t-test.
+func welch(a, b []float64) float64 {
+	if len(a) < 2 || len(b) < 2 {
+		return 0
+	}
+
+	meanA, varA := meanVariance(a)
+	meanB, varB := meanVariance(b)
+
+	se := math.Sqrt(varA/float64(len(a)) + varB/float64(len(b)))
+	if se == 0 {
+		return 0
+	}
+	return (meanA - meanB) / se
+}
+
+func meanVariance(values []float64) (float64, float64) {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+
+	var sq float64
+	for _, v := range values {
+		sq += (v - mean) * (v - mean)
+	}
+	return mean, sq / float64(len(values)-1)
+}
@@ -0,0 +1,41 @@
+package dudect_test
+
+import (
+	"math"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/OpenSlides/vote-decrypt/internal/dudect"
+)
+
+func busy(d time.Duration) {
+	for start := time.Now(); time.Since(start) < d; {
+	}
+}
+
+func TestMeasureLeak(t *testing.T) {
+	got := dudect.Measure(2_000, func(class int) func() {
+		return func() { busy(time.Duration(class+1) * 20 * time.Microsecond) }
+	})
+
+	if math.Abs(got) < dudect.Threshold {
+		t.Errorf("got t=%.2f for a leaking function, expected more than %d", got, dudect.Threshold)
+	}
+}
+
+// TestMeasureConstant can fail on a busy machine. So it only runs, when the
+// environment variable VOTE_DECRYPT_TIMING_TEST is set.
+func TestMeasureConstant(t *testing.T) {
+	if os.Getenv("VOTE_DECRYPT_TIMING_TEST") == "" {
+		t.Skip("set VOTE_DECRYPT_TIMING_TEST to run the timing test")
+	}
+
+	got := dudect.Measure(2_000, func(class int) func() {
+		return func() { busy(20 * time.Microsecond) }
+	})
+
+	if math.Abs(got) > dudect.Threshold {
+		t.Errorf("got t=%.2f for a constant function, expected less than %d", got, dudect.Threshold)
+	}
+}
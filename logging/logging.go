@@ -0,0 +1,99 @@
+// Package logging creates the logger of the service.
+//
+// The service must never log vote plaintexts, ciphertexts or key material. Log
+// messages should only contain poll ids, counts and error classes. As a second
+// line of defense, the logger created by New() redacts all attributes, that
+// contain raw bytes.
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/OpenSlides/vote-decrypt/errorcode"
+)
+
+// Redacted is the value, that replaces byte values in log messages.
+const Redacted = "[redacted]"
+
+// New returns a logger, that writes to w.
+//
+// format can be `text` or `json`. level can be `debug`, `info`, `warn` or
+// `error`.
+func New(w io.Writer, format string, level string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q: %w", level, err)
+	}
+
+	options := &slog.HandlerOptions{
+		Level:       lvl,
+		ReplaceAttr: redactBytes,
+	}
+
+	switch strings.ToLower(format) {
+	case "text":
+		return slog.New(slog.NewTextHandler(w, options)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, options)), nil
+	default:
+		return nil, fmt.Errorf("invalid log format %q, use text or json", format)
+	}
+}
+
+// redactBytes replaces attributes with raw bytes.
+func redactBytes(groups []string, a slog.Attr) slog.Attr {
+	if a.Value.Kind() != slog.KindAny {
+		return a
+	}
+
+	switch a.Value.Any().(type) {
+	case []byte, [][]byte:
+		return slog.String(a.Key, Redacted)
+	}
+	return a
+}
+
+// ErrorClass returns the class of an error that can be logged without the
+// error message.
+//
+// It is the name of the errorcode, `deadline_exceeded` or `canceled` for
+// context errors, or `internal` for all other errors. Errors with a method
+// `ErrorClass() string` define their own class.
+func ErrorClass(err error) string {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return "deadline_exceeded"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	}
+
+	var classErr interface{ ErrorClass() string }
+	if errors.As(err, &classErr) {
+		return classErr.ErrorClass()
+	}
+
+	var errCode errorcode.DecryptError
+	if !errors.As(err, &errCode) {
+		return "internal"
+	}
+
+	switch errCode {
+	case errorcode.Exist:
+		return "exist"
+	case errorcode.NotExist:
+		return "not_exist"
+	case errorcode.Invalid:
+		return "invalid"
+	case errorcode.WrongState:
+		return "wrong_state"
+	case errorcode.Exhausted:
+		return "exhausted"
+	default:
+		return "unknown"
+	}
+}
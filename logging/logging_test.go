@@ -0,0 +1,73 @@
+package logging_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/OpenSlides/vote-decrypt/errorcode"
+	"github.com/OpenSlides/vote-decrypt/logging"
+)
+
+func TestNew(t *testing.T) {
+	for _, format := range []string{"text", "json"} {
+		t.Run(format, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			logger, err := logging.New(buf, format, "info")
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+
+			logger.Debug("hidden")
+			logger.Info("message", "poll_id", "test/1", "key", []byte("secret"), "votes", [][]byte{[]byte("secret")})
+
+			out := buf.String()
+			if strings.Contains(out, "hidden") {
+				t.Errorf("debug message was logged with level info")
+			}
+
+			if !strings.Contains(out, "test/1") {
+				t.Errorf("poll id was not logged: %s", out)
+			}
+
+			if strings.Contains(out, "secret") || strings.Contains(out, "c2VjcmV0") {
+				t.Errorf("bytes were not redacted: %s", out)
+			}
+		})
+	}
+
+	t.Run("invalid format", func(t *testing.T) {
+		if _, err := logging.New(new(bytes.Buffer), "xml", "info"); err == nil {
+			t.Errorf("New did not return an error")
+		}
+	})
+
+	t.Run("invalid level", func(t *testing.T) {
+		if _, err := logging.New(new(bytes.Buffer), "text", "loud"); err == nil {
+			t.Errorf("New did not return an error")
+		}
+	})
+}
+
+func TestErrorClass(t *testing.T) {
+	for _, tt := range []struct {
+		err    error
+		expect string
+	}{
+		{fmt.Errorf("wrapped: %w", errorcode.NotExist), "not_exist"},
+		{errorcode.WrongState, "wrong_state"},
+		{errors.New("something"), "internal"},
+		{fmt.Errorf("wrapped: %w", classError{}), "custom"},
+	} {
+		if got := logging.ErrorClass(tt.err); got != tt.expect {
+			t.Errorf("ErrorClass(%v) returned %s, expected %s", tt.err, got, tt.expect)
+		}
+	}
+}
+
+type classError struct{}
+
+func (classError) Error() string      { return "class error" }
+func (classError) ErrorClass() string { return "custom" }
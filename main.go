@@ -3,18 +3,33 @@ package main
 import (
 	"context"
 	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/base64"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/OpenSlides/vote-decrypt/audit"
+	"github.com/OpenSlides/vote-decrypt/auth"
 	"github.com/OpenSlides/vote-decrypt/crypto"
 	"github.com/OpenSlides/vote-decrypt/decrypt"
 	"github.com/OpenSlides/vote-decrypt/grpc"
+	"github.com/OpenSlides/vote-decrypt/logging"
+	"github.com/OpenSlides/vote-decrypt/metrics"
 	"github.com/OpenSlides/vote-decrypt/store"
+	"github.com/OpenSlides/vote-decrypt/store/memory"
+	"github.com/OpenSlides/vote-decrypt/store/redis"
+	"github.com/OpenSlides/vote-decrypt/store/sqlite"
+	"github.com/OpenSlides/vote-decrypt/tracing"
+	"github.com/OpenSlides/vote-decrypt/transparency"
 	"github.com/alecthomas/kong"
+	goredis "github.com/redis/go-redis/v9"
 	"golang.org/x/sys/unix"
 )
 
@@ -24,7 +39,12 @@ func main() {
 
 	cliCtx := kong.Parse(&cli, kong.UsageOnError())
 
-	var err error
+	logger, err := logging.New(os.Stderr, cli.LogFormat, cli.LogLevel)
+	if err != nil {
+		cliCtx.FatalIfErrorf(err)
+	}
+	slog.SetDefault(logger)
+
 	switch cliCtx.Command() {
 	case "server <main-key>":
 		err = runServer(ctx)
@@ -35,22 +55,67 @@ func main() {
 	case "pub-key <main-key>":
 		err = runPubKey(ctx)
 
+	case "gc":
+		err = runGC(ctx)
+
+	case "audit verify <audit-log>":
+		err = runAuditVerify(ctx)
+
+	case "store migrate":
+		err = runStoreMigrate(ctx)
+
+	case "healthcheck":
+		err = runHealthcheck(ctx)
+
 	default:
 		panic(fmt.Sprintf("Unknown command: %s", cliCtx.Command()))
 	}
 
 	if err != nil {
-		log.Printf("Error: %v", err)
+		slog.Error("command failed", "error", err)
 		os.Exit(1)
 	}
 }
 
 var cli struct {
+	LogFormat string `help:"Format of the log output. One of text or json." env:"VOTE_DECRYPT_LOG_FORMAT" default:"text" enum:"text,json"`
+	LogLevel  string `help:"Minimum level of log messages. One of debug, info, warn or error." env:"VOTE_DECRYPT_LOG_LEVEL" default:"info" enum:"debug,info,warn,error"`
+
 	Server struct {
 		MainKey *os.File `arg:"" help:"Path to the main key file."`
 
-		Port  int    `help:"Port for the server. Defaults to 9014." short:"p" env:"VOTE_DECRYPT_PORT" default:"9014"`
-		Store string `help:"Path for the file system storage of poll keys." env:"VOTE_DECRYPT_STORE" default:"vote_data"`
+		Port         int    `help:"Port for the server. Defaults to 9014." short:"p" env:"VOTE_DECRYPT_PORT" default:"9014"`
+		AdminPort    int    `help:"Port for the admin service. Defaults to the port of the server, if api tokens or client certificates are configured. Disabled otherwise." env:"VOTE_DECRYPT_ADMIN_PORT"`
+		HTTPPort     int    `help:"Port for the http gateway. Disabled, if not set." env:"VOTE_DECRYPT_HTTP_PORT" name:"http-port"`
+		MetricsPort  int    `help:"Port for the prometheus metrics. Metrics are disabled, if not set." env:"VOTE_DECRYPT_METRICS_PORT"`
+		OTLPEndpoint string `help:"Address of an OTLP collector (grpc) to export traces, for example localhost:4317. Tracing is disabled, if not set." env:"VOTE_DECRYPT_OTLP_ENDPOINT" name:"otlp-endpoint"`
+
+		StoreFlags `embed:""`
+
+		PollTTL          time.Duration `help:"Default time to live for a poll. 0 means, that polls do not expire." env:"VOTE_DECRYPT_POLL_TTL" default:"0" name:"poll-ttl"`
+		JanitorInterval  time.Duration `help:"Interval to remove expired polls." env:"VOTE_DECRYPT_JANITOR_INTERVAL" default:"1m"`
+		TenantKeys       string        `help:"Path to a directory with main keys for tenants. Each file TENANT.key is the main key of the tenant." env:"VOTE_DECRYPT_TENANT_KEYS"`
+		Reflection       bool          `help:"Enables the grpc reflection service for debugging." env:"VOTE_DECRYPT_REFLECTION"`
+		TransparencyPort int           `help:"Port for the public key transparency endpoint. Disabled, if not set." env:"VOTE_DECRYPT_TRANSPARENCY_PORT"`
+		TransparencyLog  string        `help:"Path to the log of public poll keys. Defaults to the file transparency.log in the store." env:"VOTE_DECRYPT_TRANSPARENCY_LOG"`
+		AuditLog         string        `help:"Path to the audit log file. Use 'store' to write the audit log with the store backend. Not supported by the redis backend. Disabled, if not set." env:"VOTE_DECRYPT_AUDIT_LOG"`
+		CommitReveal     bool          `help:"Orders the votes with a committed seed and a public contribution, so the order can be verified." env:"VOTE_DECRYPT_COMMIT_REVEAL"`
+
+		MaxMessageSize     int           `help:"Maximum size of a request in bytes." env:"VOTE_DECRYPT_MAX_MESSAGE_SIZE" default:"4194304"`
+		MaxVotes           int           `help:"Maximum number of votes per poll. 0 means no limit." env:"VOTE_DECRYPT_MAX_VOTES" default:"0"`
+		MaxVoteSize        int           `help:"Maximum size of one encrypted vote in bytes. 0 means no limit." env:"VOTE_DECRYPT_MAX_VOTE_SIZE" default:"0"`
+		MaxConcurrentStops int           `help:"Maximum number of Stop calls, that are processed at the same time. 0 means no limit." env:"VOTE_DECRYPT_MAX_CONCURRENT_STOPS" default:"0"`
+		DecryptWorkers     int           `help:"Number of votes, that are decrypted at the same time by all polls. 0 means the number of CPUs." env:"VOTE_DECRYPT_DECRYPT_WORKERS" default:"0"`
+		StopTimeout        time.Duration `help:"Base timeout for Stop. 0 means no timeout." env:"VOTE_DECRYPT_STOP_TIMEOUT" default:"0"`
+		StopTimeoutPerVote time.Duration `help:"Additional timeout for Stop for each vote." env:"VOTE_DECRYPT_STOP_TIMEOUT_PER_VOTE" default:"0"`
+		RateLimit          float64       `help:"Requests per second for each caller. 0 means no limit." env:"VOTE_DECRYPT_RATE_LIMIT" default:"0"`
+		RateBurst          int           `help:"Number of requests, a caller can send at once above the rate limit." env:"VOTE_DECRYPT_RATE_BURST" default:"10"`
+
+		AuthTokens     string `help:"Path to a file with api tokens. Each line has the form 'TENANT TOKEN'. Requires tls." env:"VOTE_DECRYPT_AUTH_TOKENS"`
+		InsecureTokens bool   `help:"Allows api tokens without tls. The tokens are sent in cleartext." env:"VOTE_DECRYPT_INSECURE_TOKENS"`
+		TLSCert        string `help:"Path to the tls certificate. Enables tls." env:"VOTE_DECRYPT_TLS_CERT" name:"tls-cert"`
+		TLSKey         string `help:"Path to the tls key." env:"VOTE_DECRYPT_TLS_KEY" name:"tls-key"`
+		TLSClientCA    string `help:"Path to a CA file to verify client certificates. The common name of a client certificate is used as tenant." env:"VOTE_DECRYPT_TLS_CLIENT_CA" name:"tls-client-ca"`
 	} `cmd:"" help:"Starts the vote decrypt grpc server." default:"withargs"`
 
 	MainKey struct {
@@ -62,6 +127,36 @@ var cli struct {
 		SkipNewline bool     `help:"Do not output the trailing newline." short:"n"`
 		Base64      bool     `help:"Decode the output with base64." short:"b" name:"base64"`
 	} `cmd:"" help:"Calculates the public key for a private key file"`
+
+	GC struct {
+		StoreFlags `embed:""`
+
+		AuditLog string   `help:"Path to the audit log file. Use 'store' to write the audit log with the store backend. Not supported by the redis backend. Disabled, if not set." env:"VOTE_DECRYPT_AUDIT_LOG"`
+		MainKey  *os.File `help:"Path to the main key file. Required with --audit-log, the redis backend and the snapshot of the memory backend."`
+		DryRun   bool     `help:"Only list the expired polls, do not remove them."`
+	} `cmd:"" name:"gc" help:"Removes the keys of expired polls. Should only be used, when the server is not running."`
+
+	StoreCmd struct {
+		Migrate struct {
+			Store  string `help:"Path of the file system storage to migrate." env:"VOTE_DECRYPT_STORE" default:"vote_data"`
+			SQLite string `help:"Path to the sqlite database. It has to be empty." env:"VOTE_DECRYPT_SQLITE" default:"vote.db" name:"sqlite"`
+		} `cmd:"" help:"Copies all polls from the file system storage into a sqlite database. Should only be used, when the server is not running."`
+	} `cmd:"" name:"store" help:"Commands for the storage backends."`
+
+	Healthcheck struct {
+		Host    string        `help:"Host of the server." default:"localhost"`
+		Port    int           `help:"Port of the server." env:"VOTE_DECRYPT_PORT" default:"9014"`
+		TLSCert string        `help:"Path to the tls certificate of the server. If set, tls is used without verifying the certificate." env:"VOTE_DECRYPT_TLS_CERT" name:"tls-cert"`
+		Timeout time.Duration `help:"Time to wait for the response." default:"5s"`
+	} `cmd:"" help:"Checks the health of a running server. Exits with 1, if the server is not healthy. Can be used as docker HEALTHCHECK."`
+
+	Audit struct {
+		Verify struct {
+			AuditLog  string   `arg:"" help:"Path to the audit log file."`
+			MainKey   *os.File `help:"Path to the main key file, that signed the audit log." xor:"key"`
+			PublicKey string   `help:"Public main key as base64, that signed the audit log." xor:"key"`
+		} `cmd:"" help:"Checks the integrity of an audit log."`
+	} `cmd:"" help:"Commands for the audit log."`
 }
 
 func runServer(ctx context.Context) error {
@@ -70,24 +165,351 @@ func runServer(ctx context.Context) error {
 		return fmt.Errorf("reading key: %w", err)
 	}
 
+	if cli.Server.OTLPEndpoint != "" {
+		shutdown, err := tracing.Setup(ctx, cli.Server.OTLPEndpoint)
+		if err != nil {
+			return fmt.Errorf("setup tracing: %w", err)
+		}
+
+		defer func() {
+			if err := shutdown(context.Background()); err != nil {
+				slog.Error("shutting down tracing", "error", err)
+			}
+		}()
+	}
+
 	cryptoLib := crypto.New(key, rand.Reader, nil)
 
 	fmt.Printf("Public Main Key: %s\n", base64.StdEncoding.EncodeToString(cryptoLib.PublicMainKey()))
 
+	decryptOptions := []decrypt.Option{
+		decrypt.WithPollTTL(cli.Server.PollTTL),
+		decrypt.WithLogger(slog.Default()),
+		decrypt.WithMaxVoteSize(cli.Server.MaxVoteSize),
+		decrypt.WithMaxConcurrentStops(cli.Server.MaxConcurrentStops),
+		decrypt.WithDecryptWorkers(cli.Server.DecryptWorkers),
+		decrypt.WithStopTimeout(cli.Server.StopTimeout, cli.Server.StopTimeoutPerVote),
+	}
+
+	if cli.Server.MaxVotes > 0 {
+		decryptOptions = append(decryptOptions, decrypt.WithMaxVotes(cli.Server.MaxVotes))
+	}
+
+	if cli.Server.CommitReveal {
+		decryptOptions = append(decryptOptions, decrypt.WithCommitReveal())
+	}
+
+	if cli.Server.TenantKeys != "" {
+		tenantOptions, err := loadTenantKeys(cli.Server.TenantKeys)
+		if err != nil {
+			return fmt.Errorf("loading tenant keys: %w", err)
+		}
+		decryptOptions = append(decryptOptions, tenantOptions...)
+	}
+
+	backend, closeBackend, err := openStore(ctx, cli.Server.StoreFlags, key)
+	if err != nil {
+		return fmt.Errorf("open store: %w", err)
+	}
+
+	defer func() {
+		if err := closeBackend(); err != nil {
+			slog.Error("closing store", "error", err)
+		}
+	}()
+
+	if cli.Server.AuditLog != "" {
+		auditLog, err := openAuditLog(cli.Server.AuditLog, cli.Server.StoreFlags, backend, cryptoLib)
+		if err != nil {
+			return err
+		}
+		decryptOptions = append(decryptOptions, decrypt.WithAuditLog(auditLog))
+	}
+
+	var transparencySink audit.Sink
+	if cli.Server.TransparencyPort != 0 {
+		logPath := cli.Server.TransparencyLog
+		if logPath == "" {
+			logPath = filepath.Join(cli.Server.Store, "transparency.log")
+		}
+		transparencySink = audit.NewFileSink(logPath)
+
+		keyLog, err := audit.New(transparencySink, cryptoLib, audit.WithOperations(decrypt.AuditStart))
+		if err != nil {
+			return fmt.Errorf("open transparency log: %w", err)
+		}
+		decryptOptions = append(decryptOptions, decrypt.WithAuditLog(keyLog))
+	}
+
 	decrypter := decrypt.New(
 		cryptoLib,
-		store.New(cli.Server.Store),
+		backend,
+		decryptOptions...,
 	)
 
+	if transparencySink != nil {
+		handler := transparency.New(slog.Default(), decrypter.PublicMainKeys(), transparencySink)
+		go func() {
+			if err := transparency.Serve(ctx, slog.Default(), fmt.Sprintf(":%d", cli.Server.TransparencyPort), handler); err != nil {
+				slog.Error("running transparency server", "error", err)
+			}
+		}()
+	}
+
+	go decrypter.RunJanitor(ctx, cli.Server.JanitorInterval)
+
+	if cli.Server.MetricsPort != 0 {
+		if err := metrics.RegisterActivePolls(activePollCounter(ctx, decrypter)); err != nil {
+			return fmt.Errorf("register active polls metric: %w", err)
+		}
+
+		go func() {
+			if err := metrics.Serve(ctx, fmt.Sprintf(":%d", cli.Server.MetricsPort)); err != nil {
+				slog.Error("running metrics server", "error", err)
+			}
+		}()
+	}
+
 	addr := fmt.Sprintf(":%d", cli.Server.Port)
 
-	if err := grpc.RunServer(ctx, decrypter, addr); err != nil {
+	options, err := grpcServerOptions()
+	if err != nil {
+		return fmt.Errorf("reading server config: %w", err)
+	}
+
+	if err := grpc.RunServer(ctx, decrypter, addr, options...); err != nil {
 		return fmt.Errorf("running grpc server: %w", err)
 	}
 
 	return nil
 }
 
+// storeBackend is a store, that can also be used as audit sink.
+type storeBackend interface {
+	decrypt.Store
+	audit.Sink
+}
+
+// openAuditLog opens the audit log at path. If path is "store", the log is
+// written with the store backend.
+func openAuditLog(path string, flags StoreFlags, backend storeBackend, signer audit.Signer) (*audit.Log, error) {
+	// Each instance keeps the hash of the last entry in memory. Instances,
+	// that share a redis server, would fork the hash chain.
+	if path == "store" && flags.StoreBackend == "redis" {
+		return nil, fmt.Errorf("the redis backend can not be used for the audit log. Use a file for each instance")
+	}
+
+	var sink audit.Sink = backend
+	if path != "store" {
+		sink = audit.NewFileSink(path)
+	}
+
+	auditLog, err := audit.New(sink, signer)
+	if err != nil {
+		return nil, fmt.Errorf("open audit log: %w", err)
+	}
+	return auditLog, nil
+}
+
+// StoreFlags are the cli arguments to select and configure the store backend.
+// They are used by all commands, that use the store.
+type StoreFlags struct {
+	Store            string        `help:"Path for the file system storage of poll keys." env:"VOTE_DECRYPT_STORE" default:"vote_data"`
+	StoreBackend     string        `help:"Storage backend for the poll keys. One of file, memory, sqlite or redis." env:"VOTE_DECRYPT_STORE_BACKEND" default:"file" enum:"file,memory,sqlite,redis"`
+	SQLite           string        `help:"Path to the database of the sqlite backend." env:"VOTE_DECRYPT_SQLITE" default:"vote.db" name:"sqlite"`
+	Redis            string        `help:"URL of the redis server of the redis backend." env:"VOTE_DECRYPT_REDIS" default:"redis://localhost:6379/0"`
+	RedisPrefix      string        `help:"Prefix for all keys of the redis backend." env:"VOTE_DECRYPT_REDIS_PREFIX" default:"vote-decrypt:"`
+	RedisTTL         time.Duration `help:"Time to live for the data of a poll in the redis backend. 0 means, that the data does not expire." env:"VOTE_DECRYPT_REDIS_TTL" default:"0" name:"redis-ttl"`
+	Snapshot         string        `help:"Path to an encrypted snapshot of the memory backend. Snapshots are disabled, if not set." env:"VOTE_DECRYPT_SNAPSHOT"`
+	SnapshotInterval time.Duration `help:"Interval to write the snapshot of the memory backend." env:"VOTE_DECRYPT_SNAPSHOT_INTERVAL" default:"1m"`
+}
+
+// needsMainKey returns true, if the backend encrypts its data with the main
+// key.
+func (f StoreFlags) needsMainKey() bool {
+	return f.StoreBackend == "redis" || (f.StoreBackend == "memory" && f.Snapshot != "")
+}
+
+// openStore returns the store backend from the cli arguments.
+//
+// The returned function has to be called, when the command is done.
+func openStore(ctx context.Context, flags StoreFlags, mainKey []byte) (storeBackend, func() error, error) {
+	switch flags.StoreBackend {
+	case "memory":
+		options := []memory.Option{memory.WithLogger(slog.Default())}
+		if flags.Snapshot != "" {
+			options = append(options, memory.WithSnapshot(flags.Snapshot, mainKey))
+		}
+
+		backend := memory.New(options...)
+		if err := backend.LoadSnapshot(); err != nil {
+			return nil, nil, fmt.Errorf("loading snapshot: %w", err)
+		}
+
+		go backend.RunSnapshots(ctx, flags.SnapshotInterval)
+
+		return backend, backend.Snapshot, nil
+
+	case "sqlite":
+		backend, err := sqlite.New(flags.SQLite, sqlite.WithLogger(slog.Default()))
+		if err != nil {
+			return nil, nil, fmt.Errorf("open sqlite database: %w", err)
+		}
+
+		return backend, backend.Close, nil
+
+	case "redis":
+		options, err := goredis.ParseURL(flags.Redis)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parsing redis url: %w", err)
+		}
+
+		client := goredis.NewClient(options)
+		backend := redis.New(
+			client,
+			mainKey,
+			redis.WithPrefix(flags.RedisPrefix),
+			redis.WithTTL(flags.RedisTTL),
+		)
+
+		return backend, client.Close, nil
+
+	default:
+		return store.New(flags.Store), func() error { return nil }, nil
+	}
+}
+
+// activePollCounter returns a function that counts the polls, that are not
+// cleared or expired.
+func activePollCounter(ctx context.Context, decrypter *decrypt.Decrypt) func() (int, error) {
+	return func() (int, error) {
+		polls, err := decrypter.ListPolls(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("listing polls: %w", err)
+		}
+
+		var count int
+		for _, poll := range polls {
+			switch poll.State {
+			case decrypt.StateCreated, decrypt.StateStarted, decrypt.StateStopped:
+				count++
+			}
+		}
+		return count, nil
+	}
+}
+
+// loadTenantKeys reads all main keys from a directory and returns the options
+// to use them for the tenants.
+func loadTenantKeys(dir string) ([]decrypt.Option, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.key"))
+	if err != nil {
+		return nil, fmt.Errorf("listing key files: %w", err)
+	}
+
+	var options []decrypt.Option
+	for _, file := range files {
+		tenant := strings.TrimSuffix(filepath.Base(file), ".key")
+		if err := auth.ValidateTenant(tenant); err != nil || tenant == auth.Admin {
+			return nil, fmt.Errorf("invalid tenant name of key file %s", file)
+		}
+
+		key, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("reading key file: %w", err)
+		}
+
+		if len(key) < 32 {
+			return nil, fmt.Errorf("key file %s is to short", file)
+		}
+
+		cryptoLib := crypto.New(key[:32], rand.Reader, nil)
+		fmt.Printf("Public Main Key for tenant %s: %s\n", tenant, base64.StdEncoding.EncodeToString(cryptoLib.PublicMainKey()))
+		options = append(options, decrypt.WithTenantCrypto(tenant, cryptoLib))
+	}
+
+	return options, nil
+}
+
+// grpcServerOptions returns the options for the grpc server from the cli
+// arguments.
+func grpcServerOptions() ([]grpc.ServerOption, error) {
+	options := []grpc.ServerOption{
+		grpc.WithLogger(slog.Default()),
+		grpc.WithMaxMessageSize(cli.Server.MaxMessageSize),
+	}
+
+	if cli.Server.RateLimit > 0 {
+		options = append(options, grpc.WithRateLimit(cli.Server.RateLimit, cli.Server.RateBurst))
+	}
+	if cli.Server.HTTPPort != 0 {
+		options = append(options, grpc.WithHTTPAddr(fmt.Sprintf(":%d", cli.Server.HTTPPort)))
+	}
+
+	if cli.Server.Reflection {
+		options = append(options, grpc.WithReflection())
+	}
+
+	if cli.Server.AdminPort != 0 && cli.Server.AdminPort != cli.Server.Port {
+		options = append(options, grpc.WithAdminAddr(fmt.Sprintf(":%d", cli.Server.AdminPort)))
+	}
+
+	if cli.Server.AdminPort == cli.Server.Port && cli.Server.AuthTokens == "" && cli.Server.TLSClientCA == "" {
+		return nil, fmt.Errorf("the admin service on the port of the server requires api tokens or client certificates")
+	}
+
+	if cli.Server.AuthTokens != "" {
+		f, err := os.Open(cli.Server.AuthTokens)
+		if err != nil {
+			return nil, fmt.Errorf("open token file: %w", err)
+		}
+		defer f.Close()
+
+		tokens, err := auth.ParseTokens(f)
+		if err != nil {
+			return nil, fmt.Errorf("parsing token file: %w", err)
+		}
+		options = append(options, grpc.WithTokens(tokens))
+
+		if cli.Server.InsecureTokens {
+			options = append(options, grpc.WithInsecureTokens())
+		}
+	}
+
+	if cli.Server.TLSCert != "" {
+		cert, err := tls.LoadX509KeyPair(cli.Server.TLSCert, cli.Server.TLSKey)
+		if err != nil {
+			return nil, fmt.Errorf("loading tls certificate: %w", err)
+		}
+
+		tlsConfig := &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+		}
+
+		if cli.Server.TLSClientCA != "" {
+			caPEM, err := os.ReadFile(cli.Server.TLSClientCA)
+			if err != nil {
+				return nil, fmt.Errorf("reading client ca: %w", err)
+			}
+
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(caPEM) {
+				return nil, fmt.Errorf("no certificate found in client ca file")
+			}
+
+			tlsConfig.ClientCAs = pool
+			tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		}
+
+		options = append(options, grpc.WithTLS(tlsConfig))
+	} else if cli.Server.TLSClientCA != "" {
+		return nil, fmt.Errorf("client certificates can only be used with tls")
+	}
+
+	return options, nil
+}
+
 func runPubKey(ctx context.Context) error {
 	key := make([]byte, 32)
 	if _, err := io.ReadFull(cli.PubKey.MainKey, key); err != nil {
@@ -123,6 +545,148 @@ func runMainKey(ctx context.Context) error {
 	return nil
 }
 
+func runGC(ctx context.Context) error {
+	var key []byte
+	switch {
+	case cli.GC.MainKey != nil:
+		key = make([]byte, 32)
+		if _, err := io.ReadFull(cli.GC.MainKey, key); err != nil {
+			return fmt.Errorf("reading key: %w", err)
+		}
+
+	case cli.GC.AuditLog != "":
+		return fmt.Errorf("--main-key is required to write the audit log")
+
+	case cli.GC.needsMainKey():
+		return fmt.Errorf("--main-key is required for the %s backend", cli.GC.StoreBackend)
+	}
+
+	st, closeBackend, err := openStore(ctx, cli.GC.StoreFlags, key)
+	if err != nil {
+		return fmt.Errorf("open store: %w", err)
+	}
+
+	defer func() {
+		if err := closeBackend(); err != nil {
+			slog.Error("closing store", "error", err)
+		}
+	}()
+
+	var decryptOptions []decrypt.Option
+	if cli.GC.AuditLog != "" {
+		auditLog, err := openAuditLog(cli.GC.AuditLog, cli.GC.StoreFlags, st, crypto.New(key, rand.Reader, nil))
+		if err != nil {
+			return err
+		}
+		decryptOptions = append(decryptOptions, decrypt.WithAuditLog(auditLog))
+	}
+
+	// Expire does not use the crypto, so no main key is needed without the
+	// audit log.
+	decrypter := decrypt.New(nil, st, decryptOptions...)
+
+	polls, err := decrypt.ExpiredPolls(st, time.Now())
+	if err != nil {
+		return fmt.Errorf("loading expired polls: %w", err)
+	}
+
+	for _, poll := range polls {
+		fmt.Printf("%s\t%s\texpired since %s\n", poll.ID, poll.State, poll.Expires.Format(time.RFC3339))
+
+		if cli.GC.DryRun {
+			continue
+		}
+
+		if err := decrypter.Expire(ctx, poll.ID); err != nil {
+			return fmt.Errorf("removing poll %s: %w", poll.ID, err)
+		}
+	}
+
+	return nil
+}
+
+func runStoreMigrate(ctx context.Context) error {
+	if _, err := os.Stat(cli.StoreCmd.Migrate.Store); err != nil {
+		return fmt.Errorf("checking file system storage: %w", err)
+	}
+
+	db, err := sqlite.New(cli.StoreCmd.Migrate.SQLite)
+	if err != nil {
+		return fmt.Errorf("open sqlite database: %w", err)
+	}
+	defer db.Close()
+
+	count, err := db.Migrate(store.New(cli.StoreCmd.Migrate.Store))
+	if err != nil {
+		return fmt.Errorf("migrating: %w", err)
+	}
+
+	fmt.Printf("copied %d polls to %s\n", count, cli.StoreCmd.Migrate.SQLite)
+	return nil
+}
+
+func runHealthcheck(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, cli.Healthcheck.Timeout)
+	defer cancel()
+
+	var options []grpc.ClientOption
+	if cli.Healthcheck.TLSCert != "" {
+		// The healthcheck is meant to run next to the server, for example in
+		// the same container. So the certificate, that is probably issued for
+		// another hostname, is not verified.
+		options = append(options, grpc.WithClientTLS(&tls.Config{
+			InsecureSkipVerify: true,
+			MinVersion:         tls.VersionTLS12,
+		}))
+	}
+
+	addr := fmt.Sprintf("%s:%d", cli.Healthcheck.Host, cli.Healthcheck.Port)
+	if err := grpc.HealthCheck(ctx, addr, options...); err != nil {
+		return fmt.Errorf("checking health: %w", err)
+	}
+
+	return nil
+}
+
+func runAuditVerify(ctx context.Context) error {
+	var pubKey []byte
+	switch {
+	case cli.Audit.Verify.MainKey != nil:
+		key := make([]byte, 32)
+		if _, err := io.ReadFull(cli.Audit.Verify.MainKey, key); err != nil {
+			return fmt.Errorf("reading key: %w", err)
+		}
+		pubKey = crypto.New(key, rand.Reader, nil).PublicMainKey()
+
+	case cli.Audit.Verify.PublicKey != "":
+		decoded, err := base64.StdEncoding.DecodeString(cli.Audit.Verify.PublicKey)
+		if err != nil {
+			return fmt.Errorf("decoding public key: %w", err)
+		}
+		pubKey = decoded
+
+	default:
+		return fmt.Errorf("--main-key or --public-key is required")
+	}
+
+	if _, err := os.Stat(cli.Audit.Verify.AuditLog); err != nil {
+		return fmt.Errorf("checking audit log: %w", err)
+	}
+
+	records, err := audit.NewFileSink(cli.Audit.Verify.AuditLog).AuditRecords()
+	if err != nil {
+		return fmt.Errorf("reading audit log: %w", err)
+	}
+
+	last, err := audit.Verify(records, pubKey)
+	if err != nil {
+		return fmt.Errorf("audit log is invalid: %w", err)
+	}
+
+	fmt.Printf("audit log is valid with %d entries\n", last.Seq)
+	return nil
+}
+
 // interruptContext works like signal.NotifyContext. It returns a context that
 // is canceled, when a signal is received.
 //
@@ -0,0 +1,179 @@
+// Package metrics defines the prometheus metrics of the service.
+//
+// The metrics are collected in Registry and can be served with Serve().
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "vote_decrypt"
+
+var (
+	// RPCRequests counts the rpc calls by method and status code.
+	RPCRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rpc_requests_total",
+		Help:      "Number of rpc requests by method and status code.",
+	}, []string{"method", "code"})
+
+	// RPCDuration observes the duration of rpc calls by method and status
+	// code.
+	RPCDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "rpc_duration_seconds",
+		Help:      "Duration of rpc requests by method and status code.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 4, 10),
+	}, []string{"method", "code"})
+
+	// VotesDecrypted counts all votes, that were decrypted.
+	VotesDecrypted = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "votes_decrypted_total",
+		Help:      "Number of decrypted votes.",
+	})
+
+	// DecryptFailures counts the votes, that could not be decrypted and were
+	// replaced by the error value.
+	DecryptFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "decrypt_failures_total",
+		Help:      "Number of votes, that could not be decrypted.",
+	})
+
+	// DecryptDuration observes the time to shuffle and decrypt the votes of a
+	// poll by the size of the poll.
+	DecryptDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "decrypt_duration_seconds",
+		Help:      "Duration to shuffle and decrypt the votes of a poll by poll size.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 4, 10),
+	}, []string{"poll_size"})
+
+	// DecryptWorkersBusy is the number of decrypt workers, that are
+	// decrypting votes.
+	DecryptWorkersBusy = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "decrypt_workers_busy",
+		Help:      "Number of decrypt workers, that are decrypting votes.",
+	})
+
+	// DecryptQueuedVotes is the number of votes, that wait for a decrypt
+	// worker.
+	DecryptQueuedVotes = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "decrypt_queued_votes",
+		Help:      "Number of votes, that wait for a decrypt worker.",
+	})
+
+	// StoreDuration observes the duration of store operations.
+	StoreDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "store_operation_duration_seconds",
+		Help:      "Duration of store operations.",
+		Buckets:   prometheus.ExponentialBuckets(0.0001, 4, 10),
+	}, []string{"operation"})
+)
+
+// Registry contains all metrics of the service.
+var Registry = prometheus.NewRegistry()
+
+func init() {
+	Registry.MustRegister(
+		RPCRequests,
+		RPCDuration,
+		VotesDecrypted,
+		DecryptFailures,
+		DecryptWorkersBusy,
+		DecryptQueuedVotes,
+		DecryptDuration,
+		StoreDuration,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// RegisterActivePolls registers a gauge for the active polls. The function is
+// called, when the metrics are collected.
+func RegisterActivePolls(count func() (int, error)) error {
+	gauge := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "active_polls",
+		Help:      "Number of polls in the store, that are not cleared or expired.",
+	}, func() float64 {
+		n, err := count()
+		if err != nil {
+			slog.Error("counting active polls failed", "error", err)
+			return -1
+		}
+		return float64(n)
+	})
+
+	return Registry.Register(gauge)
+}
+
+// PollSize returns a label for the size of a poll.
+func PollSize(votes int) string {
+	switch {
+	case votes <= 10:
+		return "10"
+	case votes <= 100:
+		return "100"
+	case votes <= 1_000:
+		return "1000"
+	case votes <= 10_000:
+		return "10000"
+	default:
+		return "more"
+	}
+}
+
+// ObserveStore observes the duration of a store operation. It should be called
+// with defer:
+//
+//	defer metrics.ObserveStore("load_key", time.Now())
+func ObserveStore(operation string, start time.Time) {
+	StoreDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+}
+
+// Serve runs a http server on the given address that serves the metrics on
+// the path /metrics until the context is done.
+func Serve(ctx context.Context, addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(Registry, promhttp.HandlerOpts{}))
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+
+	wait := make(chan struct{})
+	go func() {
+		<-ctx.Done()
+		if err := srv.Shutdown(context.Background()); err != nil {
+			slog.Error("shutting down metrics server", "error", err)
+		}
+		close(wait)
+	}()
+
+	slog.Info("running metrics server", "addr", addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("running metrics server: %w", err)
+	}
+
+	<-wait
+
+	return nil
+}
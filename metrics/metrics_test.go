@@ -0,0 +1,71 @@
+package metrics_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OpenSlides/vote-decrypt/metrics"
+)
+
+func TestPollSize(t *testing.T) {
+	for votes, expect := range map[int]string{
+		0:      "10",
+		10:     "10",
+		11:     "100",
+		1_000:  "1000",
+		10_000: "10000",
+		10_001: "more",
+	} {
+		if got := metrics.PollSize(votes); got != expect {
+			t.Errorf("PollSize(%d) returned %s, expected %s", votes, got, expect)
+		}
+	}
+}
+
+func TestServe(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("finding free port: %v", err)
+	}
+	addr := lis.Addr().String()
+	lis.Close()
+
+	metrics.VotesDecrypted.Add(1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- metrics.Serve(ctx, addr)
+	}()
+
+	var body string
+	for i := 0; i < 100; i++ {
+		resp, err := http.Get("http://" + addr + "/metrics")
+		if err != nil {
+			time.Sleep(10 * time.Millisecond)
+			continue
+		}
+
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("reading body: %v", err)
+		}
+		body = string(data)
+		break
+	}
+
+	if !strings.Contains(body, "vote_decrypt_votes_decrypted_total") {
+		t.Errorf("metrics do not contain vote_decrypt_votes_decrypted_total:\n%s", body)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Serve: %v", err)
+	}
+}
@@ -0,0 +1,584 @@
+// Package memory is a storrage backend for vote-decrypt that keeps all data in
+// memory.
+//
+// The private poll keys never touch the disk, unless snapshots are enabled.
+// Snapshots are encrypted with a key, that is derived from the main key.
+package memory
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/OpenSlides/vote-decrypt/decrypt"
+	"github.com/OpenSlides/vote-decrypt/errorcode"
+	"github.com/OpenSlides/vote-decrypt/metrics"
+	"golang.org/x/crypto/hkdf"
+)
+
+// snapshotLabel is used to derive the snapshot key from the main key and as
+// additional data of the encryption. It has to be changed, if the format of
+// the snapshot changes.
+const snapshotLabel = "vote-decrypt memory snapshot v1"
+
+// Option for memory.New().
+type Option = func(*Store)
+
+// WithSnapshot enables snapshots to the file path.
+//
+// The snapshot is encrypted with aes-gcm. The key is derived from mainKey with
+// hkdf, so the snapshot can only be read with the same main key.
+func WithSnapshot(path string, mainKey []byte) Option {
+	return func(s *Store) {
+		s.snapshotPath = path
+		s.snapshotKey = snapshotKey(mainKey)
+	}
+}
+
+// WithLogger sets the logger for errors of RunSnapshots. Uses slog.Default()
+// as default.
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *Store) {
+		s.logger = logger
+	}
+}
+
+// Store implements the decrypt.Store interface by keeping the data in memory.
+//
+// All data is lost, when the process ends. With the option WithSnapshot, the
+// data can be written to an encrypted file with Snapshot or RunSnapshots and
+// restored with LoadSnapshot. When a poll is cleared, a snapshot is written at
+// once, so the key of the poll is also removed from the file.
+//
+// The store also implements audit.Sink and keeps the audit log in memory.
+type Store struct {
+	mu     sync.Mutex
+	logger *slog.Logger
+
+	keys       map[string][]byte
+	signatures map[string][]byte
+	polls      map[string]decrypt.Poll
+	audit      [][]byte
+
+	snapshotPath string
+	snapshotKey  []byte
+
+	// snapshotMu makes sure, that only one snapshot is written at a time.
+	snapshotMu sync.Mutex
+
+	// changed is true, if the data was changed since the last snapshot.
+	changed bool
+
+	// snapshotErr is the error of the last snapshot.
+	snapshotErr error
+}
+
+// New initializes a new Store.
+func New(options ...Option) *Store {
+	s := &Store{
+		keys:       make(map[string][]byte),
+		signatures: make(map[string][]byte),
+		polls:      make(map[string]decrypt.Poll),
+		logger:     slog.Default(),
+	}
+
+	for _, o := range options {
+		o(s)
+	}
+
+	return s
+}
+
+// SaveKey stores the private key.
+//
+// Has to return an error, if a key already exists.
+func (s *Store) SaveKey(id string, key []byte) error {
+	defer metrics.ObserveStore("save_key", time.Now())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.keys[id]; ok {
+		return errorcode.Exist
+	}
+
+	s.saveKey(id, key)
+	return nil
+}
+
+// LoadOrCreateKey returns the private key of the poll. If the poll has no key,
+// a new key is created with the create function and saved.
+func (s *Store) LoadOrCreateKey(id string, create func() ([]byte, error)) ([]byte, bool, error) {
+	defer metrics.ObserveStore("load_or_create_key", time.Now())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key, ok := s.keys[id]; ok {
+		return bytes.Clone(key), false, nil
+	}
+
+	key, err := create()
+	if err != nil {
+		return nil, false, fmt.Errorf("creating key: %w", err)
+	}
+
+	s.saveKey(id, key)
+	return key, true, nil
+}
+
+// saveKey saves a copy of the key and replaces the poll. Has to be called with
+// the lock.
+func (s *Store) saveKey(id string, key []byte) {
+	s.keys[id] = bytes.Clone(key)
+	s.polls[id] = decrypt.Poll{ID: id, State: decrypt.StateCreated, Created: time.Now()}
+	s.changed = true
+}
+
+// LoadKey returns the private key from the store.
+//
+// The returned slice is a copy, so it stays valid after the poll is cleared.
+func (s *Store) LoadKey(id string) ([]byte, error) {
+	defer metrics.ObserveStore("load_key", time.Now())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.keys[id]
+	if !ok {
+		return nil, errorcode.NotExist
+	}
+
+	return bytes.Clone(key), nil
+}
+
+// ValidateSignature makes sure, that no other signature is saved for a
+// poll. Saves the signature for future calls.
+func (s *Store) ValidateSignature(id string, hash []byte) error {
+	defer metrics.ObserveStore("validate_signature", time.Now())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.keys[id]; !ok {
+		return errorcode.NotExist
+	}
+
+	saved, ok := s.signatures[id]
+	if !ok {
+		s.signatures[id] = bytes.Clone(hash)
+		s.changed = true
+		return nil
+	}
+
+	if subtle.ConstantTimeCompare(hash, saved) != 1 {
+		return errorcode.Invalid
+	}
+
+	return nil
+}
+
+// ClearPoll removes all data for the poll except the meta data.
+//
+// The key is overwritten with zeros before it is removed.
+//
+// If snapshots are enabled, a snapshot is written before ClearPoll returns. So
+// the key is also removed from the snapshot file.
+func (s *Store) ClearPoll(id string) error {
+	defer metrics.ObserveStore("clear_poll", time.Now())
+
+	if !s.clearPoll(id) {
+		return nil
+	}
+
+	if err := s.Snapshot(); err != nil {
+		return fmt.Errorf("removing poll from snapshot: %w", err)
+	}
+	return nil
+}
+
+// clearPoll removes the key and the signature of a poll. Returns true, if the
+// data was changed.
+func (s *Store) clearPoll(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	poll, ok := s.polls[id]
+	if !ok {
+		return false
+	}
+
+	_, hadKey := s.keys[id]
+	clear(s.keys[id])
+	delete(s.keys, id)
+	delete(s.signatures, id)
+
+	if poll.State == decrypt.StateCleared {
+		return hadKey
+	}
+
+	poll.State = decrypt.StateCleared
+	poll.SetTime(decrypt.StateCleared, time.Now())
+	s.polls[id] = poll
+	s.changed = true
+	return true
+}
+
+// LoadPoll returns the meta data of a poll.
+func (s *Store) LoadPoll(id string) (decrypt.Poll, error) {
+	defer metrics.ObserveStore("load_poll", time.Now())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	poll, ok := s.polls[id]
+	if !ok {
+		return decrypt.Poll{}, errorcode.NotExist
+	}
+	return poll, nil
+}
+
+// SetState sets the state of a poll and the timestamp for the new state.
+func (s *Store) SetState(id string, state decrypt.PollState) error {
+	defer metrics.ObserveStore("set_state", time.Now())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	poll, ok := s.polls[id]
+	if !ok {
+		return errorcode.NotExist
+	}
+
+	if state == decrypt.StateStarted && !poll.State.CanStart() {
+		return errorcode.WrongState
+	}
+
+	poll.State = state
+	poll.SetTime(state, time.Now())
+	s.polls[id] = poll
+	s.changed = true
+	return nil
+}
+
+// SetExpires sets the time, when the poll expires.
+func (s *Store) SetExpires(id string, expires time.Time) error {
+	defer metrics.ObserveStore("set_expires", time.Now())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	poll, ok := s.polls[id]
+	if !ok {
+		return errorcode.NotExist
+	}
+
+	poll.Expires = expires
+	s.polls[id] = poll
+	s.changed = true
+	return nil
+}
+
+// ListPolls returns the meta data of all known polls.
+func (s *Store) ListPolls() ([]decrypt.Poll, error) {
+	defer metrics.ObserveStore("list_polls", time.Now())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	polls := make([]decrypt.Poll, 0, len(s.polls))
+	for _, poll := range s.polls {
+		polls = append(polls, poll)
+	}
+	return polls, nil
+}
+
+// AppendAudit appends a record to the audit log.
+//
+// Implements audit.Sink.
+func (s *Store) AppendAudit(record []byte) error {
+	defer metrics.ObserveStore("append_audit", time.Now())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.audit = append(s.audit, bytes.Clone(record))
+	s.changed = true
+	return nil
+}
+
+// AuditRecords returns all records of the audit log.
+//
+// Implements audit.Sink.
+func (s *Store) AuditRecords() ([][]byte, error) {
+	defer metrics.ObserveStore("audit_records", time.Now())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records := make([][]byte, len(s.audit))
+	for i, record := range s.audit {
+		records[i] = bytes.Clone(record)
+	}
+	return records, nil
+}
+
+// Health returns the error of the last snapshot.
+//
+// Implements decrypt.StoreHealthChecker.
+func (s *Store) Health() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.snapshotErr != nil {
+		return fmt.Errorf("last snapshot failed: %w", s.snapshotErr)
+	}
+	return nil
+}
+
+// snapshotContent is the content of a snapshot before it is encrypted.
+type snapshotContent struct {
+	Keys       map[string][]byte       `json:"keys"`
+	Signatures map[string][]byte       `json:"signatures"`
+	Polls      map[string]decrypt.Poll `json:"polls"`
+	Audit      [][]byte                `json:"audit"`
+}
+
+// Snapshot writes all data to the snapshot file.
+//
+// Does nothing, if snapshots are not enabled.
+func (s *Store) Snapshot() error {
+	defer metrics.ObserveStore("snapshot", time.Now())
+
+	if s.snapshotPath == "" {
+		return nil
+	}
+
+	s.snapshotMu.Lock()
+	defer s.snapshotMu.Unlock()
+
+	s.mu.Lock()
+	data, err := json.Marshal(snapshotContent{
+		Keys:       s.keys,
+		Signatures: s.signatures,
+		Polls:      s.polls,
+		Audit:      s.audit,
+	})
+	s.changed = false
+	s.mu.Unlock()
+
+	if err == nil {
+		err = s.writeSnapshot(data)
+	}
+	clear(data)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.snapshotErr = err
+	if err != nil {
+		s.changed = true
+		return fmt.Errorf("writing snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// writeSnapshot encrypts the data and writes it to a temporary file, that is
+// renamed to the snapshot file. The old snapshot file is overwritten with
+// zeros afterwards, so its content does not stay on the disk.
+//
+// The file contains the nonce followed by the ciphertext.
+func (s *Store) writeSnapshot(data []byte) error {
+	gcm, err := newGCM(s.snapshotKey)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return fmt.Errorf("read random for nonce: %w", err)
+	}
+
+	sealed := gcm.Seal(nonce, nonce, data, []byte(snapshotLabel))
+
+	if err := os.MkdirAll(filepath.Dir(s.snapshotPath), os.ModePerm); err != nil {
+		return fmt.Errorf("creating snapshot dir: %w", err)
+	}
+
+	tmpFile := s.snapshotPath + ".tmp"
+	if err := os.WriteFile(tmpFile, sealed, 0600); err != nil {
+		return fmt.Errorf("writing temporary file: %w", err)
+	}
+
+	// Open the old snapshot before the rename, so it can be overwritten
+	// afterwards. Overwriting it before the rename would lose the snapshot, if
+	// the process ends between the two steps.
+	old, err := os.OpenFile(s.snapshotPath, os.O_WRONLY, 0)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("open old snapshot: %w", err)
+	}
+
+	if err := os.Rename(tmpFile, s.snapshotPath); err != nil {
+		if old != nil {
+			old.Close()
+		}
+		return fmt.Errorf("renaming file: %w", err)
+	}
+
+	if old != nil {
+		if err := overwrite(old); err != nil {
+			return fmt.Errorf("overwriting old snapshot: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// LoadSnapshot replaces all data with the data from the snapshot file.
+//
+// Does nothing, if snapshots are not enabled or the file does not exist.
+// Returns an error, if the snapshot was written with another main key.
+func (s *Store) LoadSnapshot() error {
+	if s.snapshotPath == "" {
+		return nil
+	}
+
+	sealed, err := os.ReadFile(s.snapshotPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("reading snapshot: %w", err)
+	}
+
+	gcm, err := newGCM(s.snapshotKey)
+	if err != nil {
+		return err
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return fmt.Errorf("snapshot is to short")
+	}
+
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	data, err := gcm.Open(nil, nonce, ciphertext, []byte(snapshotLabel))
+	if err != nil {
+		return fmt.Errorf("decrypting snapshot. Was it written with another main key? %w", err)
+	}
+	defer clear(data)
+
+	var content snapshotContent
+	if err := json.Unmarshal(data, &content); err != nil {
+		return fmt.Errorf("decoding snapshot: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys = content.Keys
+	s.signatures = content.Signatures
+	s.polls = content.Polls
+	s.audit = content.Audit
+
+	if s.keys == nil {
+		s.keys = make(map[string][]byte)
+	}
+	if s.signatures == nil {
+		s.signatures = make(map[string][]byte)
+	}
+	if s.polls == nil {
+		s.polls = make(map[string]decrypt.Poll)
+	}
+	s.changed = false
+
+	return nil
+}
+
+// RunSnapshots writes a snapshot every interval until the context is done. A
+// snapshot is only written, if the data has changed.
+//
+// Does nothing, if snapshots are not enabled or interval is not positive.
+func (s *Store) RunSnapshots(ctx context.Context, interval time.Duration) {
+	if s.snapshotPath == "" || interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		s.mu.Lock()
+		changed := s.changed
+		s.mu.Unlock()
+
+		if !changed {
+			continue
+		}
+
+		if err := s.Snapshot(); err != nil {
+			s.logger.ErrorContext(ctx, "snapshot failed", "error", err)
+		}
+	}
+}
+
+// overwrite overwrites a file with zeros and closes it.
+func overwrite(f *os.File) error {
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("reading file info: %w", err)
+	}
+
+	if _, err := f.Write(make([]byte, info.Size())); err != nil {
+		return fmt.Errorf("writing zeros: %w", err)
+	}
+
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("syncing file: %w", err)
+	}
+
+	return f.Close()
+}
+
+// snapshotKey derives the key for the snapshot from the main key.
+func snapshotKey(mainKey []byte) []byte {
+	key := make([]byte, 32)
+	// hkdf only returns an error, if more then 255 blocks are read.
+	io.ReadFull(hkdf.New(sha256.New, mainKey, nil, []byte(snapshotLabel)), key)
+	return key
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("creating aes chipher: %w", err)
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("create gcm mode: %w", err)
+	}
+
+	return gcm, nil
+}
@@ -0,0 +1,315 @@
+package memory_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OpenSlides/vote-decrypt/decrypt"
+	"github.com/OpenSlides/vote-decrypt/errorcode"
+	"github.com/OpenSlides/vote-decrypt/store/memory"
+	"github.com/OpenSlides/vote-decrypt/store/storetest"
+)
+
+func TestConformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) decrypt.Store {
+		return memory.New()
+	})
+}
+
+func TestLoadKeyCopy(t *testing.T) {
+	s := memory.New()
+	if err := s.SaveKey("test/1", []byte("my key")); err != nil {
+		t.Fatalf("SaveKey: %v", err)
+	}
+
+	key, err := s.LoadKey("test/1")
+	if err != nil {
+		t.Fatalf("LoadKey: %v", err)
+	}
+
+	if err := s.ClearPoll("test/1"); err != nil {
+		t.Fatalf("ClearPoll: %v", err)
+	}
+
+	if string(key) != "my key" {
+		t.Errorf("key is %q after ClearPoll, expected the loaded key to be unchanged", key)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	mainKey := bytes.Repeat([]byte("a"), 32)
+	snapshotFile := path.Join(t.TempDir(), "snapshot")
+
+	s := memory.New(memory.WithSnapshot(snapshotFile, mainKey))
+	if err := s.SaveKey("test/1", []byte("secret poll key")); err != nil {
+		t.Fatalf("SaveKey: %v", err)
+	}
+
+	if err := s.ValidateSignature("test/1", []byte("my sig")); err != nil {
+		t.Fatalf("ValidateSignature: %v", err)
+	}
+
+	if err := s.SetState("test/1", decrypt.StateStarted); err != nil {
+		t.Fatalf("SetState: %v", err)
+	}
+
+	if err := s.AppendAudit([]byte(`{"seq":1}`)); err != nil {
+		t.Fatalf("AppendAudit: %v", err)
+	}
+
+	if err := s.Snapshot(); err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+
+	t.Run("encrypted", func(t *testing.T) {
+		content, err := os.ReadFile(snapshotFile)
+		if err != nil {
+			t.Fatalf("reading snapshot: %v", err)
+		}
+
+		if bytes.Contains(content, []byte("secret poll key")) || bytes.Contains(content, []byte("test/1")) {
+			t.Errorf("snapshot contains plaintext data")
+		}
+	})
+
+	t.Run("restore", func(t *testing.T) {
+		restored := memory.New(memory.WithSnapshot(snapshotFile, mainKey))
+		if err := restored.LoadSnapshot(); err != nil {
+			t.Fatalf("LoadSnapshot: %v", err)
+		}
+
+		key, err := restored.LoadKey("test/1")
+		if err != nil {
+			t.Fatalf("LoadKey: %v", err)
+		}
+
+		if string(key) != "secret poll key" {
+			t.Errorf("got key %q, expected %q", key, "secret poll key")
+		}
+
+		poll, err := restored.LoadPoll("test/1")
+		if err != nil {
+			t.Fatalf("LoadPoll: %v", err)
+		}
+
+		if poll.State != decrypt.StateStarted || poll.Started.IsZero() {
+			t.Errorf("got poll %v, expected a started poll", poll)
+		}
+
+		if err := restored.ValidateSignature("test/1", []byte("other sig")); err == nil {
+			t.Errorf("ValidateSignature accepted another signature after restore")
+		}
+
+		records, err := restored.AuditRecords()
+		if err != nil {
+			t.Fatalf("AuditRecords: %v", err)
+		}
+
+		if len(records) != 1 || string(records[0]) != `{"seq":1}` {
+			t.Errorf("got audit records %q", records)
+		}
+	})
+
+	t.Run("other main key", func(t *testing.T) {
+		other := memory.New(memory.WithSnapshot(snapshotFile, bytes.Repeat([]byte("b"), 32)))
+		if err := other.LoadSnapshot(); err == nil {
+			t.Errorf("LoadSnapshot with another main key did not return an error")
+		}
+	})
+
+	t.Run("no snapshot file", func(t *testing.T) {
+		empty := memory.New(memory.WithSnapshot(path.Join(t.TempDir(), "snapshot"), mainKey))
+		if err := empty.LoadSnapshot(); err != nil {
+			t.Errorf("LoadSnapshot without a file: %v", err)
+		}
+	})
+}
+
+func TestSnapshotDisabled(t *testing.T) {
+	s := memory.New()
+
+	if err := s.Snapshot(); err != nil {
+		t.Errorf("Snapshot: %v", err)
+	}
+
+	if err := s.LoadSnapshot(); err != nil {
+		t.Errorf("LoadSnapshot: %v", err)
+	}
+}
+
+func TestRunSnapshots(t *testing.T) {
+	mainKey := bytes.Repeat([]byte("a"), 32)
+	snapshotFile := path.Join(t.TempDir(), "snapshot")
+	s := memory.New(memory.WithSnapshot(snapshotFile, mainKey))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.RunSnapshots(ctx, time.Millisecond)
+		close(done)
+	}()
+
+	if err := s.SaveKey("test/1", []byte("my key")); err != nil {
+		t.Fatalf("SaveKey: %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, err := os.Stat(snapshotFile); err == nil {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("no snapshot was written")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	<-done
+}
+
+func TestHealth(t *testing.T) {
+	file := path.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatalf("creating file: %v", err)
+	}
+
+	// The snapshot can not be written, because its directory is a file.
+	s := memory.New(memory.WithSnapshot(path.Join(file, "snapshot"), make([]byte, 32)))
+
+	if err := s.Health(); err != nil {
+		t.Errorf("Health before the first snapshot: %v", err)
+	}
+
+	if err := s.Snapshot(); err == nil {
+		t.Fatalf("Snapshot did not return an error")
+	}
+
+	if err := s.Health(); err == nil {
+		t.Errorf("Health did not return the snapshot error")
+	}
+}
+
+func TestClearPollSnapshot(t *testing.T) {
+	mainKey := bytes.Repeat([]byte("a"), 32)
+	snapshotFile := path.Join(t.TempDir(), "snapshot")
+	s := memory.New(memory.WithSnapshot(snapshotFile, mainKey))
+
+	if err := s.SaveKey("test/1", []byte("my key")); err != nil {
+		t.Fatalf("SaveKey: %v", err)
+	}
+
+	if err := s.Snapshot(); err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+
+	// The link keeps the inode of the old snapshot.
+	oldSnapshot := path.Join(t.TempDir(), "old")
+	if err := os.Link(snapshotFile, oldSnapshot); err != nil {
+		t.Fatalf("linking snapshot: %v", err)
+	}
+
+	if err := s.ClearPoll("test/1"); err != nil {
+		t.Fatalf("ClearPoll: %v", err)
+	}
+
+	restored := memory.New(memory.WithSnapshot(snapshotFile, mainKey))
+	if err := restored.LoadSnapshot(); err != nil {
+		t.Fatalf("LoadSnapshot: %v", err)
+	}
+
+	if _, err := restored.LoadKey("test/1"); !errors.Is(err, errorcode.NotExist) {
+		t.Errorf("LoadKey after ClearPoll returned %v, expected %v", err, errorcode.NotExist)
+	}
+
+	content, err := os.ReadFile(oldSnapshot)
+	if err != nil {
+		t.Fatalf("reading old snapshot: %v", err)
+	}
+
+	if len(content) == 0 || !bytes.Equal(content, make([]byte, len(content))) {
+		t.Errorf("old snapshot was not overwritten with zeros")
+	}
+}
+
+func TestClearPollSnapshotError(t *testing.T) {
+	file := path.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatalf("creating file: %v", err)
+	}
+
+	s := memory.New(memory.WithSnapshot(path.Join(file, "snapshot"), make([]byte, 32)))
+	if err := s.SaveKey("test/1", []byte("my key")); err != nil {
+		t.Fatalf("SaveKey: %v", err)
+	}
+
+	if err := s.ClearPoll("test/1"); err == nil {
+		t.Errorf("ClearPoll did not return the snapshot error")
+	}
+
+	if _, err := s.LoadKey("test/1"); !errors.Is(err, errorcode.NotExist) {
+		t.Errorf("LoadKey after ClearPoll returned %v, expected %v", err, errorcode.NotExist)
+	}
+}
+
+func TestRunSnapshotsLogger(t *testing.T) {
+	file := path.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatalf("creating file: %v", err)
+	}
+
+	logs := new(syncBuffer)
+	s := memory.New(
+		memory.WithSnapshot(path.Join(file, "snapshot"), make([]byte, 32)),
+		memory.WithLogger(slog.New(slog.NewTextHandler(logs, nil))),
+	)
+
+	if err := s.SaveKey("test/1", []byte("my key")); err != nil {
+		t.Fatalf("SaveKey: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.RunSnapshots(ctx, time.Millisecond)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(logs.String(), "snapshot failed") {
+		if time.Now().After(deadline) {
+			t.Fatalf("the snapshot error was not logged")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	<-done
+}
+
+// syncBuffer is a bytes.Buffer, that can be used concurrently.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
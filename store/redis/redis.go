@@ -0,0 +1,632 @@
+// Package redis is a storrage backend for vote-decrypt that uses redis.
+//
+// It can be used to share the polls between many instances of the service.
+package redis
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenSlides/vote-decrypt/decrypt"
+	"github.com/OpenSlides/vote-decrypt/errorcode"
+	"github.com/OpenSlides/vote-decrypt/metrics"
+	goredis "github.com/redis/go-redis/v9"
+	"golang.org/x/crypto/hkdf"
+)
+
+// DefaultPrefix is the prefix for all redis keys, if WithPrefix is not used.
+const DefaultPrefix = "vote-decrypt:"
+
+// encryptionLabel is used to derive the encryption key from the main key. It
+// has to be changed, if the format of the encrypted values changes.
+const encryptionLabel = "vote-decrypt redis v1"
+
+// expireScript is the beginning of all scripts. It defines the function
+// expire, that sets the time to live of all keys of the poll. So the key, the
+// signature and the meta data of a poll always expire together.
+//
+// All scripts get the KEYS: key, signature, poll
+const expireScript = `
+local function expire(ttl)
+	if tonumber(ttl) > 0 then
+		for _, key in ipairs(KEYS) do
+			redis.call('PEXPIRE', key, ttl)
+		end
+	end
+end
+`
+
+// saveKeyScript saves the key with SETNX and replaces the meta data of the
+// poll, if the key was saved.
+//
+// ARGV: private key, poll id, state created, now, ttl in milliseconds
+var saveKeyScript = goredis.NewScript(expireScript + `
+if redis.call('SETNX', KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+
+redis.call('DEL', KEYS[2], KEYS[3])
+redis.call('HSET', KEYS[3], 'id', ARGV[2], 'state', ARGV[3], 'created', ARGV[4])
+
+expire(ARGV[5])
+return 1
+`)
+
+// signatureScript saves the signature, if the poll has a key and no
+// signature. It returns the saved signature or nil, if the poll has no key.
+//
+// The signatures are compared in go with a constant time function.
+//
+// ARGV: signature, ttl in milliseconds
+var signatureScript = goredis.NewScript(expireScript + `
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return false
+end
+
+local saved = redis.call('GET', KEYS[2])
+if not saved then
+	redis.call('SET', KEYS[2], ARGV[1])
+	saved = ARGV[1]
+end
+
+expire(ARGV[2])
+return saved
+`)
+
+// clearScript removes the key and the signature and sets the poll to the
+// state cleared. Does nothing, if the poll does not exist.
+//
+// ARGV: state cleared, now, ttl in milliseconds
+var clearScript = goredis.NewScript(expireScript + `
+if redis.call('EXISTS', KEYS[3]) == 0 then
+	return 0
+end
+
+redis.call('DEL', KEYS[1], KEYS[2])
+if redis.call('HGET', KEYS[3], 'state') ~= ARGV[1] then
+	redis.call('HSET', KEYS[3], 'state', ARGV[1], 'cleared', ARGV[2])
+end
+
+expire(ARGV[3])
+return 1
+`)
+
+// updateScript sets fields of the poll. Returns 0, if the poll does not exist
+// and -1, if the poll is not in one of the required states.
+//
+// ARGV: ttl in milliseconds, required states separated by spaces or an empty
+// string for any state, followed by field value pairs
+var updateScript = goredis.NewScript(expireScript + `
+local state = redis.call('HGET', KEYS[3], 'state')
+if not state then
+	return 0
+end
+
+if ARGV[2] ~= '' and not string.find(' ' .. ARGV[2] .. ' ', ' ' .. state .. ' ', 1, true) then
+	return -1
+end
+
+redis.call('HSET', KEYS[3], unpack(ARGV, 3))
+
+expire(ARGV[1])
+return 1
+`)
+
+// Option for redis.New().
+type Option = func(*Store)
+
+// WithPrefix sets the prefix for all redis keys. Default is DefaultPrefix.
+func WithPrefix(prefix string) Option {
+	return func(s *Store) {
+		s.prefix = prefix
+	}
+}
+
+// WithTTL sets a time to live for the data of each poll. The time starts
+// again for the key, the signature and the meta data of the poll, when the
+// poll is changed. When the time is over, redis removes the poll and it is
+// unknown.
+//
+// Default is 0, which means, that the data does not expire.
+func WithTTL(ttl time.Duration) Option {
+	return func(s *Store) {
+		s.ttl = ttl
+	}
+}
+
+// Store implements the decrypt.Store interface with redis.
+//
+// Each poll uses up to three redis keys: PREFIX[TENANT:]key:{ID} for the
+// private key, PREFIX[TENANT:]signature:{ID} for the signature and
+// PREFIX[TENANT:]poll:{ID} for a hash with the state and timestamps. TENANT is
+// the part of the poll id before the first slash. So the data of a tenant can
+// be protected with redis ACLs. The poll id is used as hash tag, so the keys
+// of one poll are on the same node of a redis cluster.
+//
+// All changes are atomic. They use SETNX or lua scripts.
+//
+// The private keys and the signatures are encrypted with aes-gcm. The key is
+// derived from the main key with hkdf, so the data can only be read with the
+// same main key. The state and the timestamps are not encrypted.
+//
+// The store also implements audit.Sink and writes the audit log into the list
+// PREFIXaudit. Only one instance can write the audit log.
+type Store struct {
+	client        goredis.UniversalClient
+	encryptionKey []byte
+	prefix        string
+	ttl           time.Duration
+
+	// createMu makes sure, that LoadOrCreateKey calls create only once in
+	// this process.
+	createMu sync.Mutex
+}
+
+// New initializes a new Store. The private keys and signatures are encrypted
+// with a key, that is derived from mainKey.
+func New(client goredis.UniversalClient, mainKey []byte, options ...Option) *Store {
+	s := &Store{
+		client:        client,
+		encryptionKey: encryptionKey(mainKey),
+		prefix:        DefaultPrefix,
+	}
+
+	for _, o := range options {
+		o(s)
+	}
+
+	return s
+}
+
+// SaveKey stores the private key.
+//
+// Has to return an error, if a key already exists.
+func (s *Store) SaveKey(id string, key []byte) error {
+	defer metrics.ObserveStore("save_key", time.Now())
+
+	sealed, err := s.seal("key", id, key)
+	if err != nil {
+		return fmt.Errorf("encrypting key: %w", err)
+	}
+
+	saved, err := saveKeyScript.Run(
+		context.Background(),
+		s.client,
+		s.pollKeys(id),
+		sealed, id, decrypt.StateCreated.String(), unixTime(time.Now()), s.ttl.Milliseconds(),
+	).Int()
+	if err != nil {
+		return fmt.Errorf("saving key: %w", err)
+	}
+
+	if saved == 0 {
+		return errorcode.Exist
+	}
+
+	return nil
+}
+
+// LoadOrCreateKey returns the private key of the poll. If the poll has no key,
+// a new key is created with the create function and saved.
+//
+// In this process, create is called at most once. If another process saves a
+// key at the same time, the key of the other process is returned.
+func (s *Store) LoadOrCreateKey(id string, create func() ([]byte, error)) ([]byte, bool, error) {
+	defer metrics.ObserveStore("load_or_create_key", time.Now())
+
+	s.createMu.Lock()
+	defer s.createMu.Unlock()
+
+	key, err := s.LoadKey(id)
+	if err == nil {
+		return key, false, nil
+	}
+
+	if !errors.Is(err, errorcode.NotExist) {
+		return nil, false, err
+	}
+
+	key, err = create()
+	if err != nil {
+		return nil, false, fmt.Errorf("creating key: %w", err)
+	}
+
+	if err := s.SaveKey(id, key); err != nil {
+		if !errors.Is(err, errorcode.Exist) {
+			return nil, false, err
+		}
+
+		// Another process created the key.
+		key, err := s.LoadKey(id)
+		if err != nil {
+			return nil, false, err
+		}
+		return key, false, nil
+	}
+
+	return key, true, nil
+}
+
+// LoadKey returns the private key from the store.
+func (s *Store) LoadKey(id string) ([]byte, error) {
+	defer metrics.ObserveStore("load_key", time.Now())
+
+	sealed, err := s.client.Get(context.Background(), s.key("key", id)).Bytes()
+	if err != nil {
+		if errors.Is(err, goredis.Nil) {
+			return nil, errorcode.NotExist
+		}
+		return nil, fmt.Errorf("reading key: %w", err)
+	}
+
+	key, err := s.open("key", id, sealed)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting key. Was it written with another main key? %w", err)
+	}
+
+	return key, nil
+}
+
+// ValidateSignature makes sure, that no other signature is saved for a
+// poll. Saves the signature for future calls.
+func (s *Store) ValidateSignature(id string, hash []byte) error {
+	defer metrics.ObserveStore("validate_signature", time.Now())
+
+	sealed, err := s.seal("signature", id, hash)
+	if err != nil {
+		return fmt.Errorf("encrypting signature: %w", err)
+	}
+
+	savedSealed, err := signatureScript.Run(
+		context.Background(),
+		s.client,
+		s.pollKeys(id),
+		sealed, s.ttl.Milliseconds(),
+	).Text()
+	if err != nil {
+		if errors.Is(err, goredis.Nil) {
+			return errorcode.NotExist
+		}
+		return fmt.Errorf("validating signature: %w", err)
+	}
+
+	saved, err := s.open("signature", id, []byte(savedSealed))
+	if err != nil {
+		return fmt.Errorf("decrypting signature. Was it written with another main key? %w", err)
+	}
+
+	if subtle.ConstantTimeCompare(hash, saved) != 1 {
+		return errorcode.Invalid
+	}
+
+	return nil
+}
+
+// ClearPoll removes all data for the poll except the meta data.
+func (s *Store) ClearPoll(id string) error {
+	defer metrics.ObserveStore("clear_poll", time.Now())
+
+	err := clearScript.Run(
+		context.Background(),
+		s.client,
+		s.pollKeys(id),
+		decrypt.StateCleared.String(), unixTime(time.Now()), s.ttl.Milliseconds(),
+	).Err()
+	if err != nil {
+		return fmt.Errorf("clearing poll: %w", err)
+	}
+
+	return nil
+}
+
+// LoadPoll returns the meta data of a poll.
+func (s *Store) LoadPoll(id string) (decrypt.Poll, error) {
+	defer metrics.ObserveStore("load_poll", time.Now())
+
+	fields, err := s.client.HGetAll(context.Background(), s.key("poll", id)).Result()
+	if err != nil {
+		return decrypt.Poll{}, fmt.Errorf("reading poll: %w", err)
+	}
+
+	if len(fields) == 0 {
+		return decrypt.Poll{}, errorcode.NotExist
+	}
+
+	return parsePoll(fields)
+}
+
+// SetState sets the state of a poll and the timestamp for the new state.
+func (s *Store) SetState(id string, state decrypt.PollState) error {
+	defer metrics.ObserveStore("set_state", time.Now())
+
+	field, ok := stateFields[state]
+	if !ok {
+		return fmt.Errorf("unknown state %d", state)
+	}
+
+	var required string
+	if state == decrypt.StateStarted {
+		required = decrypt.StateCreated.String() + " " + decrypt.StateStarted.String()
+	}
+
+	return s.update(id, required, "state", state.String(), field, unixTime(time.Now()))
+}
+
+// SetExpires sets the time, when the poll expires.
+func (s *Store) SetExpires(id string, expires time.Time) error {
+	defer metrics.ObserveStore("set_expires", time.Now())
+
+	return s.update(id, "", "expires", unixTime(expires))
+}
+
+// update sets fields of the poll hash. If required is not empty, the poll has
+// to be in one of the space separated states.
+func (s *Store) update(id string, required string, fieldValues ...string) error {
+	args := []any{s.ttl.Milliseconds(), required}
+	for _, v := range fieldValues {
+		args = append(args, v)
+	}
+
+	updated, err := updateScript.Run(context.Background(), s.client, s.pollKeys(id), args...).Int()
+	if err != nil {
+		return fmt.Errorf("updating poll: %w", err)
+	}
+
+	switch updated {
+	case 0:
+		return errorcode.NotExist
+	case -1:
+		return errorcode.WrongState
+	}
+	return nil
+}
+
+// ListPolls returns the meta data of all known polls.
+func (s *Store) ListPolls() ([]decrypt.Poll, error) {
+	defer metrics.ObserveStore("list_polls", time.Now())
+
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	var keys []string
+	scan := func(ctx context.Context, client *goredis.Client) error {
+		iter := client.ScanType(ctx, 0, escapeGlob(s.prefix)+"*poll:{*}", 100, "hash").Iterator()
+		for iter.Next(ctx) {
+			mu.Lock()
+			keys = append(keys, iter.Val())
+			mu.Unlock()
+		}
+		return iter.Err()
+	}
+
+	var err error
+	switch client := s.client.(type) {
+	case *goredis.ClusterClient:
+		err = client.ForEachMaster(ctx, scan)
+	case *goredis.Client:
+		err = scan(ctx, client)
+	default:
+		err = fmt.Errorf("unsupported redis client %T", s.client)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("scanning polls: %w", err)
+	}
+
+	polls := make([]decrypt.Poll, 0, len(keys))
+	for _, key := range keys {
+		fields, err := s.client.HGetAll(ctx, key).Result()
+		if err != nil {
+			return nil, fmt.Errorf("reading poll: %w", err)
+		}
+
+		// The poll expired after the scan.
+		if len(fields) == 0 {
+			continue
+		}
+
+		poll, err := parsePoll(fields)
+		if err != nil {
+			return nil, fmt.Errorf("parsing poll %s: %w", key, err)
+		}
+		polls = append(polls, poll)
+	}
+
+	return polls, nil
+}
+
+// Health checks, that redis can be reached.
+//
+// Implements decrypt.StoreHealthChecker.
+func (s *Store) Health() error {
+	if err := s.client.Ping(context.Background()).Err(); err != nil {
+		return fmt.Errorf("redis is not reachable: %w", err)
+	}
+	return nil
+}
+
+// AppendAudit appends a record to the audit log.
+//
+// The entries of the audit log point to the previous entry. Many instances
+// appending to the same list would fork this chain. So the list can only be
+// used by one instance.
+//
+// Implements audit.Sink.
+func (s *Store) AppendAudit(record []byte) error {
+	defer metrics.ObserveStore("append_audit", time.Now())
+
+	if err := s.client.RPush(context.Background(), s.prefix+"audit", record).Err(); err != nil {
+		return fmt.Errorf("writing record: %w", err)
+	}
+	return nil
+}
+
+// AuditRecords returns all records of the audit log.
+//
+// Implements audit.Sink.
+func (s *Store) AuditRecords() ([][]byte, error) {
+	defer metrics.ObserveStore("audit_records", time.Now())
+
+	values, err := s.client.LRange(context.Background(), s.prefix+"audit", 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("reading audit log: %w", err)
+	}
+
+	records := make([][]byte, len(values))
+	for i, v := range values {
+		records[i] = []byte(v)
+	}
+	return records, nil
+}
+
+// key returns the redis key of the given kind for a poll.
+func (s *Store) key(kind string, id string) string {
+	prefix := s.prefix
+	if tenant, _, found := strings.Cut(id, "/"); found {
+		prefix += tenant + ":"
+	}
+	return prefix + kind + ":{" + id + "}"
+}
+
+// seal encrypts a value of the given kind for a poll. The result contains the
+// nonce followed by the ciphertext.
+//
+// The kind and the poll id are used as additional data, so a value can not be
+// copied to another poll.
+func (s *Store) seal(kind string, id string, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(s.encryptionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("read random for nonce: %w", err)
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, []byte(kind+":"+id)), nil
+}
+
+// open decrypts a value, that was encrypted with seal.
+func (s *Store) open(kind string, id string, sealed []byte) ([]byte, error) {
+	gcm, err := newGCM(s.encryptionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return nil, fmt.Errorf("value is to short")
+	}
+
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, []byte(kind+":"+id))
+}
+
+// encryptionKey derives the key for the encryption from the main key.
+func encryptionKey(mainKey []byte) []byte {
+	key := make([]byte, 32)
+	// hkdf only returns an error, if more then 255 blocks are read.
+	io.ReadFull(hkdf.New(sha256.New, mainKey, nil, []byte(encryptionLabel)), key)
+	return key
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("creating aes chipher: %w", err)
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("create gcm mode: %w", err)
+	}
+
+	return gcm, nil
+}
+
+// pollKeys returns the redis keys of a poll in the order, that the scripts
+// expect.
+func (s *Store) pollKeys(id string) []string {
+	return []string{s.key("key", id), s.key("signature", id), s.key("poll", id)}
+}
+
+// stateFields are the hash fields for the timestamp of each state.
+var stateFields = map[decrypt.PollState]string{
+	decrypt.StateCreated: "created",
+	decrypt.StateStarted: "started",
+	decrypt.StateStopped: "stopped",
+	decrypt.StateCleared: "cleared",
+	decrypt.StateExpired: "expired",
+}
+
+func parsePoll(fields map[string]string) (decrypt.Poll, error) {
+	poll := decrypt.Poll{ID: fields["id"]}
+
+	if err := poll.State.UnmarshalText([]byte(fields["state"])); err != nil {
+		return decrypt.Poll{}, fmt.Errorf("decoding state: %w", err)
+	}
+
+	for _, field := range []struct {
+		name string
+		dst  *time.Time
+	}{
+		{"expires", &poll.Expires},
+		{"created", &poll.Created},
+		{"started", &poll.Started},
+		{"stopped", &poll.Stopped},
+		{"cleared", &poll.Cleared},
+		{"expired", &poll.Expired},
+	} {
+		t, err := fromUnixTime(fields[field.name])
+		if err != nil {
+			return decrypt.Poll{}, fmt.Errorf("decoding %s: %w", field.name, err)
+		}
+		*field.dst = t
+	}
+
+	return poll, nil
+}
+
+// unixTime converts a time to unix nanoseconds. The zero time is saved as an
+// empty string.
+func unixTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func fromUnixTime(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+
+	nano, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, nano), nil
+}
+
+// escapeGlob escapes the special characters of a redis pattern.
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		switch c {
+		case '*', '?', '[', ']', '\\':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,275 @@
+package redis_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OpenSlides/vote-decrypt/decrypt"
+	"github.com/OpenSlides/vote-decrypt/errorcode"
+	"github.com/OpenSlides/vote-decrypt/store/redis"
+	"github.com/OpenSlides/vote-decrypt/store/storetest"
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// newClient returns a client to the redis server from the environment
+// variable VOTE_DECRYPT_TEST_REDIS, for example redis://localhost:6379/0.
+// Without the variable, it starts a miniredis server.
+//
+// The returned miniredis server is nil, if a real redis server is used.
+func newClient(t *testing.T) (goredis.UniversalClient, *miniredis.Miniredis) {
+	t.Helper()
+
+	var mr *miniredis.Miniredis
+	url := os.Getenv("VOTE_DECRYPT_TEST_REDIS")
+	if url == "" {
+		mr = miniredis.RunT(t)
+		url = "redis://" + mr.Addr()
+	}
+
+	options, err := goredis.ParseURL(url)
+	if err != nil {
+		t.Fatalf("parsing redis url: %v", err)
+	}
+
+	client := goredis.NewClient(options)
+	t.Cleanup(func() { client.Close() })
+	return client, mr
+}
+
+var mainKey = bytes.Repeat([]byte("a"), 32)
+
+// newStore returns a store with a random prefix, so the tests do not see the
+// data of other tests on a real redis server.
+func newStore(t *testing.T, options ...redis.Option) (*redis.Store, *miniredis.Miniredis) {
+	t.Helper()
+
+	client, mr := newClient(t)
+
+	prefix := make([]byte, 8)
+	if _, err := rand.Read(prefix); err != nil {
+		t.Fatalf("creating prefix: %v", err)
+	}
+
+	options = append([]redis.Option{redis.WithPrefix("test-" + hex.EncodeToString(prefix) + ":")}, options...)
+	return redis.New(client, mainKey, options...), mr
+}
+
+func TestConformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) decrypt.Store {
+		s, _ := newStore(t)
+		return s
+	})
+}
+
+func TestTenantPrefix(t *testing.T) {
+	client, mr := newClient(t)
+	if mr == nil {
+		t.Skip("test needs miniredis")
+	}
+
+	s := redis.New(client, mainKey, redis.WithPrefix("vd:"))
+
+	if err := s.SaveKey("tenant1/5", []byte("my key")); err != nil {
+		t.Fatalf("SaveKey: %v", err)
+	}
+
+	if err := s.SaveKey("7", []byte("my key")); err != nil {
+		t.Fatalf("SaveKey: %v", err)
+	}
+
+	for _, key := range []string{
+		"vd:tenant1:key:{tenant1/5}",
+		"vd:tenant1:poll:{tenant1/5}",
+		"vd:key:{7}",
+		"vd:poll:{7}",
+	} {
+		if !mr.Exists(key) {
+			t.Errorf("redis key %s does not exist. Got keys: %v", key, mr.Keys())
+		}
+	}
+
+	polls, err := s.ListPolls()
+	if err != nil {
+		t.Fatalf("ListPolls: %v", err)
+	}
+
+	if len(polls) != 2 {
+		t.Errorf("ListPolls returned %v, expected two polls", polls)
+	}
+}
+
+func TestEncryption(t *testing.T) {
+	client, mr := newClient(t)
+	if mr == nil {
+		t.Skip("test needs miniredis")
+	}
+
+	s := redis.New(client, mainKey, redis.WithPrefix("vd:"))
+
+	if err := s.SaveKey("test/1", []byte("my key")); err != nil {
+		t.Fatalf("SaveKey: %v", err)
+	}
+
+	if err := s.ValidateSignature("test/1", []byte("my sig")); err != nil {
+		t.Fatalf("ValidateSignature: %v", err)
+	}
+
+	for key, plaintext := range map[string]string{
+		"vd:test:key:{test/1}":       "my key",
+		"vd:test:signature:{test/1}": "my sig",
+	} {
+		value, err := mr.Get(key)
+		if err != nil {
+			t.Fatalf("reading %s: %v", key, err)
+		}
+
+		if strings.Contains(value, plaintext) {
+			t.Errorf("redis key %s contains the plaintext", key)
+		}
+	}
+
+	other := redis.New(client, bytes.Repeat([]byte("b"), 32), redis.WithPrefix("vd:"))
+
+	if _, err := other.LoadKey("test/1"); err == nil || errors.Is(err, errorcode.NotExist) {
+		t.Errorf("LoadKey with another main key returned %v, expected a decryption error", err)
+	}
+
+	if err := other.ValidateSignature("test/1", []byte("my sig")); err == nil {
+		t.Errorf("ValidateSignature with another main key did not return an error")
+	}
+
+	// A value copied to another poll can not be decrypted.
+	sealed, _ := mr.Get("vd:test:key:{test/1}")
+	if err := s.SaveKey("test/2", []byte("other key")); err != nil {
+		t.Fatalf("SaveKey: %v", err)
+	}
+	mr.Set("vd:test:key:{test/2}", sealed)
+
+	if _, err := s.LoadKey("test/2"); err == nil {
+		t.Errorf("LoadKey of a copied key did not return an error")
+	}
+}
+
+func TestTTL(t *testing.T) {
+	s, mr := newStore(t, redis.WithTTL(time.Hour))
+	if mr == nil {
+		t.Skip("test needs miniredis")
+	}
+
+	if err := s.SaveKey("test/1", []byte("my key")); err != nil {
+		t.Fatalf("SaveKey: %v", err)
+	}
+
+	mr.FastForward(30 * time.Minute)
+
+	if err := s.SetState("test/1", decrypt.StateStarted); err != nil {
+		t.Fatalf("SetState: %v", err)
+	}
+
+	if err := s.ValidateSignature("test/1", []byte("my sig")); err != nil {
+		t.Fatalf("ValidateSignature: %v", err)
+	}
+
+	mr.FastForward(45 * time.Minute)
+
+	// SetState and ValidateSignature started the time again for all data of
+	// the poll.
+	key, err := s.LoadKey("test/1")
+	if err != nil {
+		t.Fatalf("LoadKey after SetState: %v", err)
+	}
+
+	if string(key) != "my key" {
+		t.Errorf("got key %q, expected %q", key, "my key")
+	}
+
+	if err := s.ValidateSignature("test/1", []byte("other sig")); !errors.Is(err, errorcode.Invalid) {
+		t.Errorf("ValidateSignature with another signature returned %v, expected %v", err, errorcode.Invalid)
+	}
+
+	mr.FastForward(2 * time.Hour)
+
+	if _, err := s.LoadKey("test/1"); !errors.Is(err, errorcode.NotExist) {
+		t.Errorf("LoadKey after the ttl returned %v, expected %v", err, errorcode.NotExist)
+	}
+
+	if _, err := s.LoadPoll("test/1"); !errors.Is(err, errorcode.NotExist) {
+		t.Errorf("LoadPoll after the ttl returned %v, expected %v", err, errorcode.NotExist)
+	}
+}
+
+func TestTTLSetState(t *testing.T) {
+	s, mr := newStore(t, redis.WithTTL(time.Hour))
+	if mr == nil {
+		t.Skip("test needs miniredis")
+	}
+
+	if err := s.SaveKey("test/1", []byte("my key")); err != nil {
+		t.Fatalf("SaveKey: %v", err)
+	}
+
+	mr.FastForward(45 * time.Minute)
+
+	if err := s.SetState("test/1", decrypt.StateStarted); err != nil {
+		t.Fatalf("SetState: %v", err)
+	}
+
+	mr.FastForward(45 * time.Minute)
+
+	if _, err := s.LoadKey("test/1"); err != nil {
+		t.Errorf("LoadKey after the first ttl: %v", err)
+	}
+}
+
+func TestAudit(t *testing.T) {
+	s, _ := newStore(t)
+
+	records, err := s.AuditRecords()
+	if err != nil {
+		t.Fatalf("AuditRecords: %v", err)
+	}
+
+	if len(records) != 0 {
+		t.Errorf("got records %q, expected none", records)
+	}
+
+	for _, record := range []string{`{"seq":1}`, `{"seq":2}`} {
+		if err := s.AppendAudit([]byte(record)); err != nil {
+			t.Fatalf("AppendAudit: %v", err)
+		}
+	}
+
+	records, err = s.AuditRecords()
+	if err != nil {
+		t.Fatalf("AuditRecords: %v", err)
+	}
+
+	if len(records) != 2 || string(records[1]) != `{"seq":2}` {
+		t.Errorf("got records %q", records)
+	}
+}
+
+func TestHealth(t *testing.T) {
+	s, mr := newStore(t)
+
+	if err := s.Health(); err != nil {
+		t.Errorf("Health: %v", err)
+	}
+
+	if mr == nil {
+		return
+	}
+
+	mr.Close()
+
+	if err := s.Health(); err == nil {
+		t.Errorf("Health with a stopped server did not return an error")
+	}
+}
@@ -0,0 +1,718 @@
+// Package sqlite is a storrage backend for vote-decrypt that uses an embedded
+// sqlite database.
+//
+// It uses a sqlite driver written in go, so it does not need cgo.
+package sqlite
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/OpenSlides/vote-decrypt/decrypt"
+	"github.com/OpenSlides/vote-decrypt/errorcode"
+	"github.com/OpenSlides/vote-decrypt/metrics"
+	"github.com/OpenSlides/vote-decrypt/store"
+
+	// Registers the driver "sqlite".
+	_ "modernc.org/sqlite"
+)
+
+// schema creates the tables. The version of the schema is saved with the
+// pragma user_version.
+const schema = `
+CREATE TABLE IF NOT EXISTS polls (
+	id        TEXT PRIMARY KEY,
+	state     TEXT NOT NULL,
+	key       BLOB,
+	signature BLOB,
+	expires   INTEGER,
+	created   INTEGER,
+	started   INTEGER,
+	stopped   INTEGER,
+	cleared   INTEGER,
+	expired   INTEGER
+);
+
+CREATE TABLE IF NOT EXISTS audit (
+	seq    INTEGER PRIMARY KEY AUTOINCREMENT,
+	record BLOB NOT NULL
+);
+
+PRAGMA user_version = 1;
+`
+
+const (
+	// busyTimeout is the time to wait for the lock of another connection.
+	busyTimeout = 10 * time.Second
+
+	// checkpointTimeout is the time a checkpoint waits for other connections.
+	// It is shorter than busyTimeout, since a blocked checkpoint is retried
+	// later.
+	checkpointTimeout = time.Second
+)
+
+// pollColumns are the columns, that are read by scanPoll.
+const pollColumns = `id, state, expires, created, started, stopped, cleared, expired`
+
+// Store implements the decrypt.Store interface with a sqlite database.
+//
+// All data of a poll is saved in one row. Each method runs in one transaction,
+// so a poll is never written partly. The database uses the WAL mode and syncs
+// each commit to the disk.
+//
+// Deleted data is overwritten with zeros (pragma secure_delete). Since the
+// old pages stay in the wal file, ClearPoll runs a checkpoint, that truncates
+// the wal file. If the checkpoint is blocked by another connection, it is
+// retried later.
+//
+// The store also implements audit.Sink and writes the audit log into the table
+// `audit`.
+type Store struct {
+	db     *sql.DB
+	logger *slog.Logger
+
+	retryDelay time.Duration
+	retryMu    sync.Mutex
+	retry      *time.Timer
+	closed     bool
+}
+
+// Option for sqlite.New().
+type Option = func(*Store)
+
+// WithLogger sets the logger for failed checkpoints. Uses slog.Default() if
+// not set.
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *Store) {
+		s.logger = logger
+	}
+}
+
+// WithCheckpointRetry sets the time to wait before a failed checkpoint is
+// retried. Default is 10 seconds.
+func WithCheckpointRetry(delay time.Duration) Option {
+	return func(s *Store) {
+		s.retryDelay = delay
+	}
+}
+
+// New opens the database at the given path. It is created, if it does not
+// exist.
+//
+// Close has to be called, when the store is not needed anymore.
+func New(path string, options ...Option) (*Store, error) {
+	pragmas := url.Values{
+		"_pragma": {
+			"journal_mode(WAL)",
+			"synchronous(FULL)",
+			fmt.Sprintf("busy_timeout(%d)", busyTimeout.Milliseconds()),
+			"secure_delete(ON)",
+		},
+		// Transactions take the write lock when they begin. This prevents
+		// deadlocks, when two transactions want to upgrade a read lock.
+		"_txlock": {"immediate"},
+	}
+
+	db, err := sql.Open("sqlite", "file:"+path+"?"+pragmas.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("creating schema: %w", err)
+	}
+
+	st := &Store{
+		db:         db,
+		logger:     slog.Default(),
+		retryDelay: 10 * time.Second,
+	}
+
+	for _, o := range options {
+		o(st)
+	}
+
+	return st, nil
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	s.retryMu.Lock()
+	s.closed = true
+	if s.retry != nil {
+		s.retry.Stop()
+	}
+	s.retryMu.Unlock()
+
+	return s.db.Close()
+}
+
+// SaveKey stores the private key.
+//
+// Has to return an error, if a key already exists.
+func (s *Store) SaveKey(id string, key []byte) error {
+	defer metrics.ObserveStore("save_key", time.Now())
+
+	return s.inTx(func(tx *sql.Tx) error {
+		return saveKey(tx, id, key)
+	})
+}
+
+// LoadOrCreateKey returns the private key of the poll. If the poll has no key,
+// a new key is created with the create function and saved.
+//
+// The transaction holds the write lock of the database, so create is called
+// at most once, even by other processes using the same database.
+func (s *Store) LoadOrCreateKey(id string, create func() ([]byte, error)) ([]byte, bool, error) {
+	defer metrics.ObserveStore("load_or_create_key", time.Now())
+
+	var key []byte
+	var created bool
+	err := s.inTx(func(tx *sql.Tx) error {
+		var err error
+		key, err = loadKey(tx, id)
+		if err == nil {
+			return nil
+		}
+
+		if !errors.Is(err, errorcode.NotExist) {
+			return err
+		}
+
+		key, err = create()
+		if err != nil {
+			return fmt.Errorf("creating key: %w", err)
+		}
+
+		created = true
+		return saveKey(tx, id, key)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return key, created, nil
+}
+
+// LoadKey returns the private key from the store.
+func (s *Store) LoadKey(id string) ([]byte, error) {
+	defer metrics.ObserveStore("load_key", time.Now())
+
+	return loadKey(s.db, id)
+}
+
+// ValidateSignature makes sure, that no other signature is saved for a
+// poll. Saves the signature for future calls.
+func (s *Store) ValidateSignature(id string, hash []byte) error {
+	defer metrics.ObserveStore("validate_signature", time.Now())
+
+	return s.inTx(func(tx *sql.Tx) error {
+		return validateSignature(tx, id, hash)
+	})
+}
+
+// SaveStopResult validates the signature and sets the poll to the state
+// stopped in one transaction.
+//
+// Implements decrypt.StopResultSaver.
+func (s *Store) SaveStopResult(id string, signature []byte) error {
+	defer metrics.ObserveStore("save_stop_result", time.Now())
+
+	return s.inTx(func(tx *sql.Tx) error {
+		if err := validateSignature(tx, id, signature); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(
+			`UPDATE polls SET state = ?, stopped = ? WHERE id = ? AND state != ?`,
+			decrypt.StateStopped.String(), unixTime(time.Now()), id, decrypt.StateStopped.String(),
+		)
+		if err != nil {
+			return fmt.Errorf("setting state: %w", err)
+		}
+		return nil
+	})
+}
+
+// ClearPoll removes all data for the poll except the meta data.
+//
+// The poll is cleared, even when the following checkpoint fails. In this case,
+// the error is logged and the checkpoint is retried later.
+func (s *Store) ClearPoll(id string) error {
+	defer metrics.ObserveStore("clear_poll", time.Now())
+
+	_, err := s.db.Exec(
+		`UPDATE polls
+		SET key = NULL, signature = NULL, state = ?, cleared = CASE WHEN state = ? THEN cleared ELSE ? END
+		WHERE id = ?`,
+		decrypt.StateCleared.String(), decrypt.StateCleared.String(), unixTime(time.Now()), id,
+	)
+	if err != nil {
+		return fmt.Errorf("clearing poll: %w", err)
+	}
+
+	if err := s.checkpoint(); err != nil {
+		s.logger.Warn("removing old key from the wal file failed, retry later", "error", err)
+		s.retryCheckpoint()
+	}
+
+	return nil
+}
+
+// retryCheckpoint runs the checkpoint after the retry delay. It does nothing,
+// if a retry is already scheduled.
+//
+// The next call of ClearPoll runs the checkpoint again, so a blocked
+// checkpoint is also retried on the next clear.
+func (s *Store) retryCheckpoint() {
+	s.retryMu.Lock()
+	defer s.retryMu.Unlock()
+
+	if s.closed || s.retry != nil {
+		return
+	}
+
+	s.retry = time.AfterFunc(s.retryDelay, func() {
+		s.retryMu.Lock()
+		s.retry = nil
+		closed := s.closed
+		s.retryMu.Unlock()
+
+		if closed {
+			return
+		}
+
+		if err := s.checkpoint(); err != nil {
+			s.logger.Warn("removing old key from the wal file failed, retry later", "error", err)
+			s.retryCheckpoint()
+			return
+		}
+
+		s.logger.Info("removed old key from the wal file")
+	})
+}
+
+// checkpoint writes all pages from the wal file into the database and
+// truncates the wal file.
+//
+// Without it, the old page with the key stays in the wal file until sqlite
+// runs the next checkpoint.
+func (s *Store) checkpoint() error {
+	ctx := context.Background()
+
+	// The checkpoint uses its own connection, so the busy timeout can be
+	// changed only for it.
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("getting connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf(`PRAGMA busy_timeout = %d`, checkpointTimeout.Milliseconds())); err != nil {
+		return fmt.Errorf("setting busy timeout: %w", err)
+	}
+	defer conn.ExecContext(ctx, fmt.Sprintf(`PRAGMA busy_timeout = %d`, busyTimeout.Milliseconds()))
+
+	var busy, logFrames, checkpointed int
+	err = conn.QueryRowContext(ctx, `PRAGMA wal_checkpoint(TRUNCATE)`).Scan(&busy, &logFrames, &checkpointed)
+	if err != nil {
+		return fmt.Errorf("running checkpoint: %w", err)
+	}
+
+	if busy != 0 {
+		return errors.New("checkpoint was blocked by another connection")
+	}
+
+	return nil
+}
+
+// LoadPoll returns the meta data of a poll.
+func (s *Store) LoadPoll(id string) (decrypt.Poll, error) {
+	defer metrics.ObserveStore("load_poll", time.Now())
+
+	row := s.db.QueryRow(`SELECT `+pollColumns+` FROM polls WHERE id = ?`, id)
+
+	poll, err := scanPoll(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return decrypt.Poll{}, errorcode.NotExist
+		}
+		return decrypt.Poll{}, fmt.Errorf("reading poll: %w", err)
+	}
+
+	return poll, nil
+}
+
+// SetState sets the state of a poll and the timestamp for the new state.
+func (s *Store) SetState(id string, state decrypt.PollState) error {
+	defer metrics.ObserveStore("set_state", time.Now())
+
+	column, ok := stateColumns[state]
+	if !ok {
+		return fmt.Errorf("unknown state %d", state)
+	}
+
+	return s.inTx(func(tx *sql.Tx) error {
+		var name string
+		if err := tx.QueryRow(`SELECT state FROM polls WHERE id = ?`, id).Scan(&name); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return errorcode.NotExist
+			}
+			return fmt.Errorf("reading state: %w", err)
+		}
+
+		var current decrypt.PollState
+		if err := current.UnmarshalText([]byte(name)); err != nil {
+			return fmt.Errorf("decoding state: %w", err)
+		}
+
+		if state == decrypt.StateStarted && !current.CanStart() {
+			return fmt.Errorf("poll is %s: %w", current, errorcode.WrongState)
+		}
+
+		_, err := tx.Exec(
+			`UPDATE polls SET state = ?, `+column+` = ? WHERE id = ?`,
+			state.String(), unixTime(time.Now()), id,
+		)
+		if err != nil {
+			return fmt.Errorf("setting state: %w", err)
+		}
+		return nil
+	})
+}
+
+// SetExpires sets the time, when the poll expires.
+func (s *Store) SetExpires(id string, expires time.Time) error {
+	defer metrics.ObserveStore("set_expires", time.Now())
+
+	result, err := s.db.Exec(`UPDATE polls SET expires = ? WHERE id = ?`, unixTime(expires), id)
+	if err != nil {
+		return fmt.Errorf("setting expires: %w", err)
+	}
+
+	return checkUpdated(result)
+}
+
+// ListPolls returns the meta data of all known polls.
+func (s *Store) ListPolls() ([]decrypt.Poll, error) {
+	defer metrics.ObserveStore("list_polls", time.Now())
+
+	rows, err := s.db.Query(`SELECT ` + pollColumns + ` FROM polls ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("reading polls: %w", err)
+	}
+	defer rows.Close()
+
+	var polls []decrypt.Poll
+	for rows.Next() {
+		poll, err := scanPoll(rows)
+		if err != nil {
+			return nil, fmt.Errorf("reading poll: %w", err)
+		}
+		polls = append(polls, poll)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading polls: %w", err)
+	}
+
+	return polls, nil
+}
+
+// Health checks, that the database can be reached.
+//
+// Implements decrypt.StoreHealthChecker.
+func (s *Store) Health() error {
+	if err := s.db.Ping(); err != nil {
+		return fmt.Errorf("database is not reachable: %w", err)
+	}
+	return nil
+}
+
+// AppendAudit appends a record to the audit log.
+//
+// Implements audit.Sink.
+func (s *Store) AppendAudit(record []byte) error {
+	defer metrics.ObserveStore("append_audit", time.Now())
+
+	if _, err := s.db.Exec(`INSERT INTO audit (record) VALUES (?)`, record); err != nil {
+		return fmt.Errorf("writing record: %w", err)
+	}
+	return nil
+}
+
+// AuditRecords returns all records of the audit log.
+//
+// Implements audit.Sink.
+func (s *Store) AuditRecords() ([][]byte, error) {
+	defer metrics.ObserveStore("audit_records", time.Now())
+
+	rows, err := s.db.Query(`SELECT record FROM audit ORDER BY seq`)
+	if err != nil {
+		return nil, fmt.Errorf("reading audit log: %w", err)
+	}
+	defer rows.Close()
+
+	var records [][]byte
+	for rows.Next() {
+		var record []byte
+		if err := rows.Scan(&record); err != nil {
+			return nil, fmt.Errorf("reading record: %w", err)
+		}
+		records = append(records, record)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading audit log: %w", err)
+	}
+
+	return records, nil
+}
+
+// Migrate copies all polls and the audit log from a file store into the
+// database. It returns the number of copied polls.
+//
+// The database has to be empty. All data is copied in one transaction, so
+// the database stays empty, if the migration fails.
+func (s *Store) Migrate(src *store.Store) (int, error) {
+	polls, err := src.ListPolls()
+	if err != nil {
+		return 0, fmt.Errorf("listing polls: %w", err)
+	}
+
+	records, err := src.AuditRecords()
+	if err != nil {
+		return 0, fmt.Errorf("reading audit log: %w", err)
+	}
+
+	err = s.inTx(func(tx *sql.Tx) error {
+		var count int
+		if err := tx.QueryRow(`SELECT (SELECT count(*) FROM polls) + (SELECT count(*) FROM audit)`).Scan(&count); err != nil {
+			return fmt.Errorf("checking database: %w", err)
+		}
+
+		if count > 0 {
+			return fmt.Errorf("database is not empty")
+		}
+
+		for _, poll := range polls {
+			key, err := src.LoadKey(poll.ID)
+			if err != nil && !errors.Is(err, errorcode.NotExist) {
+				return fmt.Errorf("loading key of poll %s: %w", poll.ID, err)
+			}
+
+			signature, err := src.LoadSignature(poll.ID)
+			if err != nil && !errors.Is(err, errorcode.NotExist) {
+				return fmt.Errorf("loading signature of poll %s: %w", poll.ID, err)
+			}
+
+			if err := insertPoll(tx, poll, key, signature); err != nil {
+				return fmt.Errorf("writing poll %s: %w", poll.ID, err)
+			}
+		}
+
+		for _, record := range records {
+			if _, err := tx.Exec(`INSERT INTO audit (record) VALUES (?)`, record); err != nil {
+				return fmt.Errorf("writing audit record: %w", err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(polls), nil
+}
+
+// inTx runs f in a transaction. The transaction is committed, if f returns
+// nil.
+func (s *Store) inTx(f func(tx *sql.Tx) error) (err error) {
+	tx, err := s.db.BeginTx(context.Background(), nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := f(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// queryRower is implemented by sql.DB and sql.Tx.
+type queryRower interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+func loadKey(db queryRower, id string) ([]byte, error) {
+	var key []byte
+	err := db.QueryRow(`SELECT key FROM polls WHERE id = ? AND key IS NOT NULL`, id).Scan(&key)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errorcode.NotExist
+		}
+		return nil, fmt.Errorf("reading key: %w", err)
+	}
+
+	return key, nil
+}
+
+// saveKey saves the key and replaces the meta data of a cleared poll.
+func saveKey(tx *sql.Tx, id string, key []byte) error {
+	result, err := tx.Exec(
+		`INSERT INTO polls (id, state, key, created) VALUES (?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			state = excluded.state, key = excluded.key, signature = NULL, expires = NULL,
+			created = excluded.created, started = NULL, stopped = NULL, cleared = NULL, expired = NULL
+		WHERE polls.key IS NULL`,
+		id, decrypt.StateCreated.String(), key, unixTime(time.Now()),
+	)
+	if err != nil {
+		return fmt.Errorf("writing key: %w", err)
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking result: %w", err)
+	}
+
+	if updated == 0 {
+		return errorcode.Exist
+	}
+
+	return nil
+}
+
+func validateSignature(tx *sql.Tx, id string, hash []byte) error {
+	var hasKey bool
+	var saved []byte
+	err := tx.QueryRow(`SELECT key IS NOT NULL, signature FROM polls WHERE id = ?`, id).Scan(&hasKey, &saved)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errorcode.NotExist
+		}
+		return fmt.Errorf("reading signature: %w", err)
+	}
+
+	if !hasKey {
+		return errorcode.NotExist
+	}
+
+	if saved == nil {
+		if _, err := tx.Exec(`UPDATE polls SET signature = ? WHERE id = ?`, hash, id); err != nil {
+			return fmt.Errorf("writing signature: %w", err)
+		}
+		return nil
+	}
+
+	if subtle.ConstantTimeCompare(hash, saved) != 1 {
+		return errorcode.Invalid
+	}
+
+	return nil
+}
+
+func insertPoll(tx *sql.Tx, poll decrypt.Poll, key, signature []byte) error {
+	_, err := tx.Exec(
+		`INSERT INTO polls (id, state, key, signature, expires, created, started, stopped, cleared, expired)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		poll.ID,
+		poll.State.String(),
+		key,
+		signature,
+		unixTime(poll.Expires),
+		unixTime(poll.Created),
+		unixTime(poll.Started),
+		unixTime(poll.Stopped),
+		unixTime(poll.Cleared),
+		unixTime(poll.Expired),
+	)
+	return err
+}
+
+// stateColumns are the columns for the timestamp of each state.
+var stateColumns = map[decrypt.PollState]string{
+	decrypt.StateCreated: "created",
+	decrypt.StateStarted: "started",
+	decrypt.StateStopped: "stopped",
+	decrypt.StateCleared: "cleared",
+	decrypt.StateExpired: "expired",
+}
+
+// scanner is implemented by sql.Row and sql.Rows.
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPoll(row scanner) (decrypt.Poll, error) {
+	var poll decrypt.Poll
+	var state string
+	var expires, created, started, stopped, cleared, expired sql.NullInt64
+
+	if err := row.Scan(&poll.ID, &state, &expires, &created, &started, &stopped, &cleared, &expired); err != nil {
+		return decrypt.Poll{}, err
+	}
+
+	if err := poll.State.UnmarshalText([]byte(state)); err != nil {
+		return decrypt.Poll{}, fmt.Errorf("decoding state: %w", err)
+	}
+
+	poll.Expires = fromUnixTime(expires)
+	poll.Created = fromUnixTime(created)
+	poll.Started = fromUnixTime(started)
+	poll.Stopped = fromUnixTime(stopped)
+	poll.Cleared = fromUnixTime(cleared)
+	poll.Expired = fromUnixTime(expired)
+
+	return poll, nil
+}
+
+// checkUpdated returns errorcode.NotExist, if the statement did not change a
+// row.
+func checkUpdated(result sql.Result) error {
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking result: %w", err)
+	}
+
+	if updated == 0 {
+		return errorcode.NotExist
+	}
+	return nil
+}
+
+// unixTime converts a time to unix nanoseconds. The zero time is saved as
+// NULL.
+func unixTime(t time.Time) sql.NullInt64 {
+	if t.IsZero() {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: t.UnixNano(), Valid: true}
+}
+
+func fromUnixTime(v sql.NullInt64) time.Time {
+	if !v.Valid {
+		return time.Time{}
+	}
+	return time.Unix(0, v.Int64)
+}
@@ -0,0 +1,327 @@
+package sqlite_test
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/OpenSlides/vote-decrypt/decrypt"
+	"github.com/OpenSlides/vote-decrypt/errorcode"
+	"github.com/OpenSlides/vote-decrypt/store"
+	"github.com/OpenSlides/vote-decrypt/store/sqlite"
+	"github.com/OpenSlides/vote-decrypt/store/storetest"
+)
+
+func newStore(t *testing.T) *sqlite.Store {
+	t.Helper()
+
+	s, err := sqlite.New(path.Join(t.TempDir(), "vote.db"))
+	if err != nil {
+		t.Fatalf("sqlite.New: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestConformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) decrypt.Store {
+		return newStore(t)
+	})
+}
+
+func TestSaveStopResult(t *testing.T) {
+	s := newStore(t)
+
+	if err := s.SaveKey("test/1", []byte("my key")); err != nil {
+		t.Fatalf("SaveKey: %v", err)
+	}
+
+	if err := s.SaveStopResult("test/1", []byte("my sig")); err != nil {
+		t.Fatalf("SaveStopResult: %v", err)
+	}
+
+	poll, err := s.LoadPoll("test/1")
+	if err != nil {
+		t.Fatalf("LoadPoll: %v", err)
+	}
+
+	if poll.State != decrypt.StateStopped || poll.Stopped.IsZero() {
+		t.Fatalf("got poll %v, expected a stopped poll", poll)
+	}
+
+	if err := s.SaveStopResult("test/1", []byte("my sig")); err != nil {
+		t.Errorf("second SaveStopResult: %v", err)
+	}
+
+	again, err := s.LoadPoll("test/1")
+	if err != nil {
+		t.Fatalf("LoadPoll: %v", err)
+	}
+
+	if !again.Stopped.Equal(poll.Stopped) {
+		t.Errorf("second SaveStopResult changed the stopped time")
+	}
+
+	if err := s.SaveStopResult("test/1", []byte("other sig")); !errors.Is(err, errorcode.Invalid) {
+		t.Errorf("SaveStopResult with another signature returned %v, expected %v", err, errorcode.Invalid)
+	}
+
+	if err := s.SaveStopResult("unknown/1", []byte("my sig")); !errors.Is(err, errorcode.NotExist) {
+		t.Errorf("SaveStopResult for unknown poll returned %v, expected %v", err, errorcode.NotExist)
+	}
+
+	if _, err := s.LoadPoll("unknown/1"); !errors.Is(err, errorcode.NotExist) {
+		t.Errorf("SaveStopResult created an unknown poll")
+	}
+}
+
+func TestReopen(t *testing.T) {
+	dbFile := path.Join(t.TempDir(), "vote.db")
+
+	s, err := sqlite.New(dbFile)
+	if err != nil {
+		t.Fatalf("sqlite.New: %v", err)
+	}
+
+	if err := s.SaveKey("test/1", []byte("my key")); err != nil {
+		t.Fatalf("SaveKey: %v", err)
+	}
+
+	if err := s.AppendAudit([]byte(`{"seq":1}`)); err != nil {
+		t.Fatalf("AppendAudit: %v", err)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	reopened, err := sqlite.New(dbFile)
+	if err != nil {
+		t.Fatalf("opening database again: %v", err)
+	}
+	defer reopened.Close()
+
+	key, err := reopened.LoadKey("test/1")
+	if err != nil {
+		t.Fatalf("LoadKey: %v", err)
+	}
+
+	if string(key) != "my key" {
+		t.Errorf("got key %q, expected %q", key, "my key")
+	}
+
+	records, err := reopened.AuditRecords()
+	if err != nil {
+		t.Fatalf("AuditRecords: %v", err)
+	}
+
+	if len(records) != 1 || string(records[0]) != `{"seq":1}` {
+		t.Errorf("got audit records %q", records)
+	}
+
+	if err := reopened.Health(); err != nil {
+		t.Errorf("Health: %v", err)
+	}
+}
+
+func TestWAL(t *testing.T) {
+	dbFile := path.Join(t.TempDir(), "vote.db")
+	s, err := sqlite.New(dbFile)
+	if err != nil {
+		t.Fatalf("sqlite.New: %v", err)
+	}
+	defer s.Close()
+
+	if err := s.SaveKey("test/1", []byte("my key")); err != nil {
+		t.Fatalf("SaveKey: %v", err)
+	}
+
+	if _, err := os.Stat(dbFile + "-wal"); err != nil {
+		t.Errorf("no wal file: %v", err)
+	}
+}
+
+func TestClearPollRemovesKeyFromFiles(t *testing.T) {
+	dbFile := path.Join(t.TempDir(), "vote.db")
+	s, err := sqlite.New(dbFile)
+	if err != nil {
+		t.Fatalf("sqlite.New: %v", err)
+	}
+	defer s.Close()
+
+	key := []byte("my secret poll key")
+	if err := s.SaveKey("test/1", key); err != nil {
+		t.Fatalf("SaveKey: %v", err)
+	}
+
+	if err := s.ClearPoll("test/1"); err != nil {
+		t.Fatalf("ClearPoll: %v", err)
+	}
+
+	for _, file := range []string{dbFile, dbFile + "-wal"} {
+		content, err := os.ReadFile(file)
+		if err != nil && !os.IsNotExist(err) {
+			t.Fatalf("reading %s: %v", file, err)
+		}
+
+		if bytes.Contains(content, key) {
+			t.Errorf("%s still contains the key", path.Base(file))
+		}
+	}
+}
+
+func TestClearPollBlockedCheckpoint(t *testing.T) {
+	dbFile := path.Join(t.TempDir(), "vote.db")
+	s, err := sqlite.New(dbFile, sqlite.WithCheckpointRetry(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("sqlite.New: %v", err)
+	}
+	defer s.Close()
+
+	key := []byte("my secret poll key")
+	if err := s.SaveKey("test/1", key); err != nil {
+		t.Fatalf("SaveKey: %v", err)
+	}
+
+	// An open read transaction of another connection blocks the checkpoint.
+	reader, err := sql.Open("sqlite", "file:"+dbFile)
+	if err != nil {
+		t.Fatalf("open second connection: %v", err)
+	}
+	defer reader.Close()
+
+	tx, err := reader.Begin()
+	if err != nil {
+		t.Fatalf("begin read transaction: %v", err)
+	}
+
+	var count int
+	if err := tx.QueryRow(`SELECT count(*) FROM polls`).Scan(&count); err != nil {
+		t.Fatalf("reading polls: %v", err)
+	}
+
+	if err := s.ClearPoll("test/1"); err != nil {
+		t.Fatalf("ClearPoll with blocked checkpoint: %v", err)
+	}
+
+	if _, err := s.LoadKey("test/1"); !errors.Is(err, errorcode.NotExist) {
+		t.Errorf("LoadKey after ClearPoll returned %v, expected %v", err, errorcode.NotExist)
+	}
+
+	tx.Rollback()
+
+	// The checkpoint is retried in the background.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		content, err := os.ReadFile(dbFile + "-wal")
+		if err != nil && !os.IsNotExist(err) {
+			t.Fatalf("reading wal file: %v", err)
+		}
+
+		if !bytes.Contains(content, key) {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("wal file still contains the key after retry")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	fileStore := store.New(t.TempDir())
+
+	for i, state := range []decrypt.PollState{decrypt.StateCreated, decrypt.StateStopped, decrypt.StateCleared} {
+		id := fmt.Sprintf("test/%d", i+1)
+		if err := fileStore.SaveKey(id, []byte("key "+id)); err != nil {
+			t.Fatalf("SaveKey: %v", err)
+		}
+
+		switch state {
+		case decrypt.StateStopped:
+			if err := fileStore.ValidateSignature(id, []byte("sig "+id)); err != nil {
+				t.Fatalf("ValidateSignature: %v", err)
+			}
+
+			if err := fileStore.SetState(id, decrypt.StateStopped); err != nil {
+				t.Fatalf("SetState: %v", err)
+			}
+
+		case decrypt.StateCleared:
+			if err := fileStore.ClearPoll(id); err != nil {
+				t.Fatalf("ClearPoll: %v", err)
+			}
+		}
+	}
+
+	expires := time.Now().Add(time.Hour).Truncate(time.Second)
+	if err := fileStore.SetExpires("test/1", expires); err != nil {
+		t.Fatalf("SetExpires: %v", err)
+	}
+
+	if err := fileStore.AppendAudit([]byte(`{"seq":1}`)); err != nil {
+		t.Fatalf("AppendAudit: %v", err)
+	}
+
+	s := newStore(t)
+
+	count, err := s.Migrate(fileStore)
+	if err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+
+	if count != 3 {
+		t.Errorf("Migrate copied %d polls, expected 3", count)
+	}
+
+	key, err := s.LoadKey("test/1")
+	if err != nil {
+		t.Fatalf("LoadKey: %v", err)
+	}
+
+	if string(key) != "key test/1" {
+		t.Errorf("got key %q, expected %q", key, "key test/1")
+	}
+
+	poll, err := s.LoadPoll("test/1")
+	if err != nil {
+		t.Fatalf("LoadPoll: %v", err)
+	}
+
+	if !poll.Expires.Equal(expires) {
+		t.Errorf("poll expires at %v, expected %v", poll.Expires, expires)
+	}
+
+	if err := s.ValidateSignature("test/2", []byte("other sig")); !errors.Is(err, errorcode.Invalid) {
+		t.Errorf("ValidateSignature with another signature returned %v, expected %v", err, errorcode.Invalid)
+	}
+
+	if poll, err := s.LoadPoll("test/2"); err != nil || poll.State != decrypt.StateStopped {
+		t.Errorf("got poll %v (%v), expected a stopped poll", poll, err)
+	}
+
+	if _, err := s.LoadKey("test/3"); !errors.Is(err, errorcode.NotExist) {
+		t.Errorf("LoadKey of cleared poll returned %v, expected %v", err, errorcode.NotExist)
+	}
+
+	records, err := s.AuditRecords()
+	if err != nil {
+		t.Fatalf("AuditRecords: %v", err)
+	}
+
+	if len(records) != 1 {
+		t.Errorf("got %d audit records, expected 1", len(records))
+	}
+
+	t.Run("not empty", func(t *testing.T) {
+		if _, err := s.Migrate(fileStore); err == nil {
+			t.Errorf("Migrate into a database with data did not return an error")
+		}
+	})
+}
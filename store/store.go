@@ -4,14 +4,19 @@ package store
 
 import (
 	"crypto/subtle"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"path"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/OpenSlides/vote-decrypt/audit"
+	"github.com/OpenSlides/vote-decrypt/decrypt"
 	"github.com/OpenSlides/vote-decrypt/errorcode"
+	"github.com/OpenSlides/vote-decrypt/metrics"
 )
 
 // Store implements the decrypt.Store interface by writing the data to files.
@@ -20,18 +25,42 @@ import (
 // save. If more then one process is running, it depends on the features of the
 // filesystem.
 //
-// For each poll, two files are created. `POLLID_key` that contains the private
-// key for the poll and `POLLID_hash` the contains the hash of the first stop
-// request.
+// For each poll, three files are created. `POLLID_key` that contains the
+// private key for the poll, `POLLID_hash` the contains the hash of the first
+// stop request and `POLLID_poll` that contains the state and timestamps of the
+// poll.
 //
-// TODO: Think about timing attacks when files do not exist or have wrong
-// content.
+// The store also implements audit.Sink and writes the audit log to the file
+// `audit.log`.
+//
+// If a file of a poll does not exist, the store reads or writes a dummy file
+// instead. So the time of a call does not tell, if a poll exists.
 type Store struct {
 	mu sync.Mutex
 
 	path string
 }
 
+// Dummy files, that are used for unknown polls. They are ignored by
+// ListPolls.
+const (
+	dummyKeyFile  = ".timing-key"
+	dummyHashFile = ".timing-hash"
+	dummyPollFile = ".timing-poll"
+
+	// dummyRemoveFile is removed instead of the key or hash file. See
+	// removeDummy().
+	dummyRemoveFile = ".timing-remove"
+)
+
+// Sizes of the dummy files. They match the size of a poll key, a signature
+// and a poll file.
+const (
+	dummyKeySize  = 32
+	dummyHashSize = 64
+	dummyPollSize = 256
+)
+
 // New initializes a new Store.
 func New(path string) *Store {
 	return &Store{
@@ -43,9 +72,56 @@ func New(path string) *Store {
 //
 // Has to return an error, if a key already exists.
 func (s *Store) SaveKey(id string, key []byte) error {
+	defer metrics.ObserveStore("save_key", time.Now())
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	return s.saveKey(id, key)
+}
+
+// LoadOrCreateKey returns the private key of the poll. If the poll has no key,
+// a new key is created with the create function and saved.
+//
+// Other processes using the same directory are protected by creating the key
+// file exclusively.
+func (s *Store) LoadOrCreateKey(id string, create func() ([]byte, error)) ([]byte, bool, error) {
+	defer metrics.ObserveStore("load_or_create_key", time.Now())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, err := s.loadKey(id)
+	if err == nil {
+		return key, false, nil
+	}
+
+	if !errors.Is(err, errorcode.NotExist) {
+		return nil, false, err
+	}
+
+	key, err = create()
+	if err != nil {
+		return nil, false, fmt.Errorf("creating key: %w", err)
+	}
+
+	if err := s.saveKey(id, key); err != nil {
+		if !errors.Is(err, errorcode.Exist) {
+			return nil, false, err
+		}
+
+		// Another process created the key.
+		key, err := s.loadKey(id)
+		if err != nil {
+			return nil, false, err
+		}
+		return key, false, nil
+	}
+
+	return key, true, nil
+}
+
+func (s *Store) saveKey(id string, key []byte) (err error) {
 	if s.path == "" {
 		return fmt.Errorf("No data dir provided. Check the environment variable VOTE_DECRYPT_STORE")
 	}
@@ -64,7 +140,7 @@ func (s *Store) SaveKey(id string, key []byte) error {
 
 	defer func() {
 		if cErr := f.Close(); err == nil && cErr != nil {
-			err = fmt.Errorf("closing file: %w", err)
+			err = fmt.Errorf("closing file: %w", cErr)
 		}
 	}()
 
@@ -72,6 +148,11 @@ func (s *Store) SaveKey(id string, key []byte) error {
 		return fmt.Errorf("writing key: %w", err)
 	}
 
+	poll := decrypt.Poll{ID: id, State: decrypt.StateCreated, Created: time.Now()}
+	if err := s.writePoll(poll); err != nil {
+		return fmt.Errorf("writing poll: %w", err)
+	}
+
 	return nil
 }
 
@@ -79,12 +160,19 @@ func (s *Store) SaveKey(id string, key []byte) error {
 //
 // If the poll is unknown return (nil, nil)
 func (s *Store) LoadKey(id string) ([]byte, error) {
+	defer metrics.ObserveStore("load_key", time.Now())
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	return s.loadKey(id)
+}
+
+func (s *Store) loadKey(id string) ([]byte, error) {
 	key, err := os.ReadFile(s.keyFile(id))
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
+			s.readDummy(dummyKeyFile, dummyKeySize)
 			return nil, errorcode.NotExist
 		}
 		return nil, fmt.Errorf("reading key file: %w", err)
@@ -98,11 +186,14 @@ func (s *Store) LoadKey(id string) ([]byte, error) {
 //
 // Has to return an error if the id is unknown in the store.
 func (s *Store) ValidateSignature(id string, hash []byte) error {
+	defer metrics.ObserveStore("validate_signature", time.Now())
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	if _, err := os.Stat(s.keyFile(id)); err != nil {
 		if errors.Is(err, os.ErrNotExist) {
+			s.createDummy(dummyHashFile, dummyHashSize)
 			return errorcode.NotExist
 		}
 
@@ -131,6 +222,26 @@ func (s *Store) ValidateSignature(id string, hash []byte) error {
 	return nil
 }
 
+// LoadSignature returns the signature, that was saved by ValidateSignature.
+//
+// Returns `errorcode.NotExist`, if no signature was saved for the poll.
+func (s *Store) LoadSignature(id string) ([]byte, error) {
+	defer metrics.ObserveStore("load_signature", time.Now())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hash, err := os.ReadFile(s.hashFile(id))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, errorcode.NotExist
+		}
+		return nil, fmt.Errorf("reading hash file: %w", err)
+	}
+
+	return hash, nil
+}
+
 func (s *Store) checkHash(id string, hash []byte) error {
 	content, err := os.ReadFile(s.hashFile(id))
 	if err != nil {
@@ -144,22 +255,414 @@ func (s *Store) checkHash(id string, hash []byte) error {
 	return nil
 }
 
-// ClearPoll removes all data for the poll.
+// ClearPoll removes all data for the poll except the meta data.
 func (s *Store) ClearPoll(id string) error {
+	defer metrics.ObserveStore("clear_poll", time.Now())
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if err := os.Remove(s.keyFile(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+	poll, err := s.loadPoll(id)
+	if err != nil {
+		if errors.Is(err, errorcode.NotExist) {
+			s.removeDummy(dummyKeySize)
+			s.removeDummy(dummyHashSize)
+			s.writeDummyPoll()
+			return nil
+		}
+		return fmt.Errorf("loading poll: %w", err)
+	}
+
+	if err := s.secureRemove(s.keyFile(id), dummyKeySize); err != nil {
 		return fmt.Errorf("deleting key file: %w", err)
 	}
 
-	if err := os.Remove(s.hashFile(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+	if err := s.secureRemove(s.hashFile(id), dummyHashSize); err != nil {
 		return fmt.Errorf("deleting hash file: %w", err)
 	}
 
+	if poll.State == decrypt.StateCleared {
+		s.writeDummyPoll()
+		return nil
+	}
+
+	poll.State = decrypt.StateCleared
+	poll.SetTime(decrypt.StateCleared, time.Now())
+	if err := s.writePoll(poll); err != nil {
+		return fmt.Errorf("writing poll: %w", err)
+	}
+
 	return nil
 }
 
+// LoadPoll returns the meta data of a poll.
+func (s *Store) LoadPoll(id string) (decrypt.Poll, error) {
+	defer metrics.ObserveStore("load_poll", time.Now())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.loadPoll(id)
+}
+
+// SetState sets the state of a poll and the timestamp for the new state.
+func (s *Store) SetState(id string, state decrypt.PollState) error {
+	defer metrics.ObserveStore("set_state", time.Now())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	poll, err := s.loadPoll(id)
+	if err != nil {
+		return fmt.Errorf("loading poll: %w", err)
+	}
+
+	if state == decrypt.StateStarted && !poll.State.CanStart() {
+		return fmt.Errorf("poll is %s: %w", poll.State, errorcode.WrongState)
+	}
+
+	poll.State = state
+	poll.SetTime(state, time.Now())
+	if err := s.writePoll(poll); err != nil {
+		return fmt.Errorf("writing poll: %w", err)
+	}
+
+	return nil
+}
+
+// SetExpires sets the time, when the poll expires.
+func (s *Store) SetExpires(id string, expires time.Time) error {
+	defer metrics.ObserveStore("set_expires", time.Now())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	poll, err := s.loadPoll(id)
+	if err != nil {
+		return fmt.Errorf("loading poll: %w", err)
+	}
+
+	poll.Expires = expires
+	if err := s.writePoll(poll); err != nil {
+		return fmt.Errorf("writing poll: %w", err)
+	}
+
+	return nil
+}
+
+// ListPolls returns the meta data of all known polls.
+func (s *Store) ListPolls() ([]decrypt.Poll, error) {
+	defer metrics.ObserveStore("list_polls", time.Now())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := os.ReadDir(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading data dir: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	var polls []decrypt.Poll
+	for _, entry := range entries {
+		name := entry.Name()
+		ext := path.Ext(name)
+		if entry.IsDir() || (ext != ".poll" && ext != ".key") {
+			continue
+		}
+
+		id := strings.ReplaceAll(strings.TrimSuffix(name, ext), "_", "/")
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		poll, err := s.loadPoll(id)
+		if err != nil {
+			return nil, fmt.Errorf("loading poll %s: %w", id, err)
+		}
+		polls = append(polls, poll)
+	}
+
+	return polls, nil
+}
+
+// pollFileContent is the content of the poll file.
+type pollFileContent struct {
+	ID      string            `json:"id"`
+	State   decrypt.PollState `json:"state"`
+	Expires time.Time         `json:"expires"`
+	Created time.Time         `json:"created"`
+	Started time.Time         `json:"started"`
+	Stopped time.Time         `json:"stopped"`
+	Cleared time.Time         `json:"cleared"`
+	Expired time.Time         `json:"expired"`
+}
+
+// loadPoll reads the poll file. Has to be called with the lock.
+//
+// If the poll file does not exist, but the poll has a key file, the poll was
+// created with an older version of this service. In this case, the state is
+// calculated from the other files.
+func (s *Store) loadPoll(id string) (decrypt.Poll, error) {
+	data, err := os.ReadFile(s.pollFile(id))
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return decrypt.Poll{}, fmt.Errorf("reading poll file: %w", err)
+		}
+
+		return s.legacyPoll(id)
+	}
+
+	var content pollFileContent
+	if err := json.Unmarshal(data, &content); err != nil {
+		return decrypt.Poll{}, fmt.Errorf("decoding poll file: %w", err)
+	}
+
+	return decrypt.Poll{
+		ID:      id,
+		State:   content.State,
+		Expires: content.Expires,
+		Created: content.Created,
+		Started: content.Started,
+		Stopped: content.Stopped,
+		Cleared: content.Cleared,
+		Expired: content.Expired,
+	}, nil
+}
+
+func (s *Store) legacyPoll(id string) (decrypt.Poll, error) {
+	if _, err := os.Stat(s.keyFile(id)); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			s.readDummy(dummyPollFile, dummyPollSize)
+			return decrypt.Poll{}, errorcode.NotExist
+		}
+		return decrypt.Poll{}, fmt.Errorf("checking key file: %w", err)
+	}
+
+	state := decrypt.StateStarted
+	if _, err := os.Stat(s.hashFile(id)); err == nil {
+		state = decrypt.StateStopped
+	}
+
+	return decrypt.Poll{ID: id, State: state}, nil
+}
+
+// writePoll replaces the poll file. Has to be called with the lock.
+func (s *Store) writePoll(poll decrypt.Poll) error {
+	data, err := json.Marshal(pollFileContent{
+		ID:      poll.ID,
+		State:   poll.State,
+		Expires: poll.Expires,
+		Created: poll.Created,
+		Started: poll.Started,
+		Stopped: poll.Stopped,
+		Cleared: poll.Cleared,
+		Expired: poll.Expired,
+	})
+	if err != nil {
+		return fmt.Errorf("encoding poll: %w", err)
+	}
+
+	return writeFileAtomic(s.pollFile(poll.ID), data)
+}
+
+// writeFileAtomic writes to a temporary file and renames it, so the file is
+// never written partly.
+func writeFileAtomic(name string, data []byte) error {
+	tmpFile := name + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0600); err != nil {
+		return fmt.Errorf("writing temporary file: %w", err)
+	}
+
+	if err := os.Rename(tmpFile, name); err != nil {
+		return fmt.Errorf("renaming file: %w", err)
+	}
+
+	return nil
+}
+
+// readDummy reads a dummy file instead of a file, that does not exist.
+//
+// Errors are ignored.
+func (s *Store) readDummy(name string, size int) {
+	data, err := os.ReadFile(path.Join(s.path, name))
+	if err != nil || len(data) != size {
+		s.writeDummy(name, size)
+	}
+}
+
+// writeDummy overwrites a dummy file like secureRemove instead of a file, that
+// does not exist.
+//
+// Errors are ignored.
+func (s *Store) writeDummy(name string, size int) {
+	f, err := os.OpenFile(path.Join(s.path, name), os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	if _, err := f.Write(make([]byte, size)); err != nil {
+		return
+	}
+	f.Sync()
+}
+
+// removeDummy overwrites and removes a dummy file like secureRemove removes a
+// file, that does not exist.
+//
+// Instead of removing the file, it is truncated, which frees its blocks, and
+// renamed between dummyRemoveFile and dummyRemoveFile.old. This takes about
+// the same time as removing it, but the file does not have to be created
+// again, which would take much longer.
+//
+// Errors are ignored.
+func (s *Store) removeDummy(size int) {
+	from := path.Join(s.path, dummyRemoveFile)
+	to := from + ".old"
+	if _, err := os.Stat(from); err != nil {
+		from, to = to, from
+	}
+
+	os.Chmod(from, 0600)
+	f, err := os.OpenFile(from, os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return
+	}
+
+	f.Write(make([]byte, size))
+	f.Sync()
+	f.Truncate(0)
+	f.Close()
+	os.Rename(from, to)
+}
+
+// createDummy writes a dummy file like ValidateSignature writes the hash file
+// of a poll, that does not exist.
+//
+// Errors are ignored.
+func (s *Store) createDummy(name string, size int) {
+	f, err := os.OpenFile(path.Join(s.path, name), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	f.Write(make([]byte, size))
+}
+
+// writeDummyPoll writes a dummy poll file instead of a poll, that does not
+// exist.
+//
+// Errors are ignored.
+func (s *Store) writeDummyPoll() {
+	writeFileAtomic(path.Join(s.path, dummyPollFile), make([]byte, dummyPollSize))
+}
+
+// secureRemove overwrites a file with zeros before it is removed, so the
+// content can not be restored from the disk.
+//
+// Does not return an error, if the file does not exist. In this case, a dummy
+// file with dummySize bytes is removed instead. See removeDummy().
+func (s *Store) secureRemove(name string, dummySize int) error {
+	info, err := os.Stat(name)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			s.removeDummy(dummySize)
+			return nil
+		}
+		return fmt.Errorf("reading file info: %w", err)
+	}
+
+	// The files are created read only.
+	if err := os.Chmod(name, 0600); err != nil {
+		return fmt.Errorf("making file writable: %w", err)
+	}
+
+	f, err := os.OpenFile(name, os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("open file: %w", err)
+	}
+
+	if _, err := f.Write(make([]byte, info.Size())); err != nil {
+		f.Close()
+		return fmt.Errorf("overwriting file: %w", err)
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("syncing file: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("closing file: %w", err)
+	}
+
+	if err := os.Remove(name); err != nil {
+		return fmt.Errorf("removing file: %w", err)
+	}
+
+	return nil
+}
+
+// Health checks, that the data dir is writable.
+//
+// Implements decrypt.StoreHealthChecker.
+func (s *Store) Health() error {
+	if s.path == "" {
+		return fmt.Errorf("No data dir provided. Check the environment variable VOTE_DECRYPT_STORE")
+	}
+
+	if err := os.MkdirAll(s.path, os.ModePerm); err != nil {
+		return fmt.Errorf("creating data dir `%s`: %w", s.path, err)
+	}
+
+	f, err := os.CreateTemp(s.path, ".health-*")
+	if err != nil {
+		return fmt.Errorf("data dir is not writable: %w", err)
+	}
+
+	name := f.Name()
+	_, wErr := f.Write([]byte("ok"))
+	cErr := f.Close()
+	rErr := os.Remove(name)
+	if err := errors.Join(wErr, cErr, rErr); err != nil {
+		return fmt.Errorf("writing test file: %w", err)
+	}
+
+	return nil
+}
+
+// AppendAudit appends a record to the audit log in the file `audit.log`.
+//
+// Implements audit.Sink.
+func (s *Store) AppendAudit(record []byte) error {
+	defer metrics.ObserveStore("append_audit", time.Now())
+
+	if s.path == "" {
+		return fmt.Errorf("No data dir provided. Check the environment variable VOTE_DECRYPT_STORE")
+	}
+
+	return audit.NewFileSink(s.auditFile()).AppendAudit(record)
+}
+
+// AuditRecords returns all records of the audit log.
+//
+// Implements audit.Sink.
+func (s *Store) AuditRecords() ([][]byte, error) {
+	defer metrics.ObserveStore("audit_records", time.Now())
+
+	return audit.NewFileSink(s.auditFile()).AuditRecords()
+}
+
+func (s *Store) auditFile() string {
+	return path.Join(s.path, "audit.log")
+}
+
 func (s *Store) keyFile(id string) string {
 	id = strings.ReplaceAll(id, "/", "_")
 	return path.Join(s.path, id+".key")
@@ -169,3 +672,8 @@ func (s *Store) hashFile(id string) string {
 	id = strings.ReplaceAll(id, "/", "_")
 	return path.Join(s.path, id+".hash")
 }
+
+func (s *Store) pollFile(id string) string {
+	id = strings.ReplaceAll(id, "/", "_")
+	return path.Join(s.path, id+".poll")
+}
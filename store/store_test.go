@@ -7,11 +7,20 @@ import (
 	"os"
 	"path"
 	"testing"
+	"time"
 
+	"github.com/OpenSlides/vote-decrypt/decrypt"
 	"github.com/OpenSlides/vote-decrypt/errorcode"
 	"github.com/OpenSlides/vote-decrypt/store"
+	"github.com/OpenSlides/vote-decrypt/store/storetest"
 )
 
+func TestConformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) decrypt.Store {
+		return store.New(t.TempDir())
+	})
+}
+
 func TestSaveKey(t *testing.T) {
 	t.Run("valid", func(t *testing.T) {
 		tmpPath := t.TempDir()
@@ -52,6 +61,44 @@ func TestSaveKey(t *testing.T) {
 	})
 }
 
+func TestLoadOrCreateKey(t *testing.T) {
+	s := store.New(t.TempDir())
+
+	var calls int
+	create := func() ([]byte, error) {
+		calls++
+		return []byte("key"), nil
+	}
+
+	key, created, err := s.LoadOrCreateKey("test/5", create)
+	if err != nil {
+		t.Fatalf("LoadOrCreateKey: %v", err)
+	}
+
+	if string(key) != "key" || !created {
+		t.Errorf("LoadOrCreateKey returned (%s, %t), expected (key, true)", key, created)
+	}
+
+	key, created, err = s.LoadOrCreateKey("test/5", create)
+	if err != nil {
+		t.Fatalf("second LoadOrCreateKey: %v", err)
+	}
+
+	if string(key) != "key" || created {
+		t.Errorf("second LoadOrCreateKey returned (%s, %t), expected (key, false)", key, created)
+	}
+
+	if calls != 1 {
+		t.Errorf("create was called %d times, expected 1", calls)
+	}
+
+	errCreate := errors.New("create failed")
+	_, _, err = s.LoadOrCreateKey("test/6", func() ([]byte, error) { return nil, errCreate })
+	if !errors.Is(err, errCreate) {
+		t.Errorf("LoadOrCreateKey returned `%v`, expected `%v`", err, errCreate)
+	}
+}
+
 func TestLoadKey(t *testing.T) {
 	t.Run("valid", func(t *testing.T) {
 		tmpPath := t.TempDir()
@@ -140,6 +187,31 @@ func TestValidateSignature(t *testing.T) {
 	})
 }
 
+func TestLoadSignature(t *testing.T) {
+	s := store.New(t.TempDir())
+
+	if err := s.SaveKey("test/1", []byte("my key")); err != nil {
+		t.Fatalf("SaveKey: %v", err)
+	}
+
+	if _, err := s.LoadSignature("test/1"); !errors.Is(err, errorcode.NotExist) {
+		t.Errorf("LoadSignature before ValidateSignature returned %v, expected %v", err, errorcode.NotExist)
+	}
+
+	if err := s.ValidateSignature("test/1", []byte("my sig")); err != nil {
+		t.Fatalf("ValidateSignature: %v", err)
+	}
+
+	sig, err := s.LoadSignature("test/1")
+	if err != nil {
+		t.Fatalf("LoadSignature: %v", err)
+	}
+
+	if string(sig) != "my sig" {
+		t.Errorf("got signature %q, expected %q", sig, "my sig")
+	}
+}
+
 func TestClearPoll(t *testing.T) {
 	t.Run("remove files", func(t *testing.T) {
 		tmpPath := t.TempDir()
@@ -169,5 +241,223 @@ func TestClearPoll(t *testing.T) {
 		if err := s.ClearPoll("test/5"); err != nil {
 			t.Fatalf("ClearPoll: %v", err)
 		}
+
+		polls, err := s.ListPolls()
+		if err != nil {
+			t.Fatalf("ListPolls: %v", err)
+		}
+
+		if len(polls) != 0 {
+			t.Errorf("ListPolls returned %v, expected the dummy files to be ignored", polls)
+		}
+
+		if _, err := s.LoadKey("test/5"); !errors.Is(err, errorcode.NotExist) {
+			t.Errorf("LoadKey returned %v, expected %v", err, errorcode.NotExist)
+		}
+	})
+
+	t.Run("validate signature of unknown poll", func(t *testing.T) {
+		tmpPath := t.TempDir()
+		s := store.New(tmpPath)
+
+		if err := s.ValidateSignature("test/5", []byte("sig")); !errors.Is(err, errorcode.NotExist) {
+			t.Fatalf("ValidateSignature returned %v, expected %v", err, errorcode.NotExist)
+		}
+
+		info, err := os.Stat(path.Join(tmpPath, ".timing-hash"))
+		if err != nil {
+			t.Fatalf("dummy hash file was not written: %v", err)
+		}
+
+		if info.Size() != 64 {
+			t.Errorf("dummy hash file has size %d, expected 64", info.Size())
+		}
+	})
+}
+
+func TestPollState(t *testing.T) {
+	t.Run("created by save key", func(t *testing.T) {
+		s := store.New(t.TempDir())
+
+		if err := s.SaveKey("test/5", []byte("key")); err != nil {
+			t.Fatalf("SaveKey: %v", err)
+		}
+
+		poll, err := s.LoadPoll("test/5")
+		if err != nil {
+			t.Fatalf("LoadPoll: %v", err)
+		}
+
+		if poll.ID != "test/5" || poll.State != decrypt.StateCreated || poll.Created.IsZero() {
+			t.Errorf("LoadPoll returned %v, expected a created poll", poll)
+		}
 	})
+
+	t.Run("set state", func(t *testing.T) {
+		s := store.New(t.TempDir())
+
+		if err := s.SaveKey("test/5", []byte("key")); err != nil {
+			t.Fatalf("SaveKey: %v", err)
+		}
+
+		if err := s.SetState("test/5", decrypt.StateStarted); err != nil {
+			t.Fatalf("SetState: %v", err)
+		}
+
+		poll, err := s.LoadPoll("test/5")
+		if err != nil {
+			t.Fatalf("LoadPoll: %v", err)
+		}
+
+		if poll.State != decrypt.StateStarted || poll.Started.IsZero() || poll.Created.IsZero() {
+			t.Errorf("LoadPoll returned %v, expected a started poll", poll)
+		}
+	})
+
+	t.Run("clear keeps meta data", func(t *testing.T) {
+		tmpPath := t.TempDir()
+		s := store.New(tmpPath)
+
+		if err := s.SaveKey("test/5", []byte("key")); err != nil {
+			t.Fatalf("SaveKey: %v", err)
+		}
+
+		if err := s.ClearPoll("test/5"); err != nil {
+			t.Fatalf("ClearPoll: %v", err)
+		}
+
+		poll, err := s.LoadPoll("test/5")
+		if err != nil {
+			t.Fatalf("LoadPoll: %v", err)
+		}
+
+		if poll.State != decrypt.StateCleared || poll.Cleared.IsZero() {
+			t.Errorf("LoadPoll returned %v, expected a cleared poll", poll)
+		}
+
+		if _, err := s.LoadKey("test/5"); err != errorcode.NotExist {
+			t.Errorf("LoadKey returned `%v`, expected `%v`", err, errorcode.NotExist)
+		}
+	})
+
+	t.Run("poll from older version", func(t *testing.T) {
+		tmpPath := t.TempDir()
+		os.WriteFile(path.Join(tmpPath, "test_5.key"), []byte("key"), 0400)
+		os.WriteFile(path.Join(tmpPath, "test_5.hash"), []byte("hash"), 0400)
+		s := store.New(tmpPath)
+
+		poll, err := s.LoadPoll("test/5")
+		if err != nil {
+			t.Fatalf("LoadPoll: %v", err)
+		}
+
+		if poll.State != decrypt.StateStopped {
+			t.Errorf("got state %s, expected %s", poll.State, decrypt.StateStopped)
+		}
+	})
+
+	t.Run("unknown poll", func(t *testing.T) {
+		s := store.New(t.TempDir())
+
+		if _, err := s.LoadPoll("test/5"); err != errorcode.NotExist {
+			t.Errorf("LoadPoll returned `%v`, expected `%v`", err, errorcode.NotExist)
+		}
+
+		if err := s.SetState("test/5", decrypt.StateStarted); !errors.Is(err, errorcode.NotExist) {
+			t.Errorf("SetState returned `%v`, expected `%v`", err, errorcode.NotExist)
+		}
+	})
+}
+
+func TestListPolls(t *testing.T) {
+	tmpPath := t.TempDir()
+	os.WriteFile(path.Join(tmpPath, "legacy_1.key"), []byte("key"), 0400)
+	s := store.New(tmpPath)
+
+	if err := s.SaveKey("test/5", []byte("key")); err != nil {
+		t.Fatalf("SaveKey: %v", err)
+	}
+
+	expires := time.Now().Add(time.Hour).Truncate(time.Second)
+	if err := s.SetExpires("test/5", expires); err != nil {
+		t.Fatalf("SetExpires: %v", err)
+	}
+
+	polls, err := s.ListPolls()
+	if err != nil {
+		t.Fatalf("ListPolls: %v", err)
+	}
+
+	got := make(map[string]decrypt.Poll)
+	for _, poll := range polls {
+		got[poll.ID] = poll
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("ListPolls returned %v, expected two polls", polls)
+	}
+
+	if !got["test/5"].Expires.Equal(expires) {
+		t.Errorf("poll expires at %s, expected %s", got["test/5"].Expires, expires)
+	}
+
+	if got["legacy/1"].State != decrypt.StateStarted {
+		t.Errorf("legacy poll has state %s, expected %s", got["legacy/1"].State, decrypt.StateStarted)
+	}
+}
+
+func TestListPollsNoDir(t *testing.T) {
+	s := store.New(path.Join(t.TempDir(), "does_not_exist"))
+
+	polls, err := s.ListPolls()
+	if err != nil {
+		t.Fatalf("ListPolls: %v", err)
+	}
+
+	if len(polls) != 0 {
+		t.Errorf("ListPolls returned %v, expected no polls", polls)
+	}
+}
+
+func TestAudit(t *testing.T) {
+	s := store.New(t.TempDir())
+
+	records, err := s.AuditRecords()
+	if err != nil {
+		t.Fatalf("AuditRecords: %v", err)
+	}
+
+	if len(records) != 0 {
+		t.Fatalf("got %d records, expected none", len(records))
+	}
+
+	for _, record := range []string{`{"seq":1}`, `{"seq":2}`} {
+		if err := s.AppendAudit([]byte(record)); err != nil {
+			t.Fatalf("AppendAudit: %v", err)
+		}
+	}
+
+	records, err = s.AuditRecords()
+	if err != nil {
+		t.Fatalf("AuditRecords: %v", err)
+	}
+
+	if len(records) != 2 || string(records[1]) != `{"seq":2}` {
+		t.Errorf("got records %q", records)
+	}
+}
+
+func TestHealth(t *testing.T) {
+	if err := store.New(t.TempDir()).Health(); err != nil {
+		t.Errorf("Health: %v", err)
+	}
+
+	file := path.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatalf("creating file: %v", err)
+	}
+
+	if err := store.New(file).Health(); err == nil {
+		t.Errorf("Health for a file did not return an error")
+	}
 }
@@ -0,0 +1,757 @@
+// Package storetest implements tests for implementations of decrypt.Store.
+//
+// A backend can check, that it fulfills the contract of the interface, with
+// one call:
+//
+//	func TestConformance(t *testing.T) {
+//		storetest.Run(t, func(t *testing.T) decrypt.Store {
+//			return mybackend.New(...)
+//		})
+//	}
+package storetest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/OpenSlides/vote-decrypt/decrypt"
+	"github.com/OpenSlides/vote-decrypt/errorcode"
+)
+
+// concurrency is the number of goroutines used by the concurrency tests.
+const concurrency = 20
+
+// Run runs all conformance tests against the store.
+//
+// newStore is called once for each test and has to return an empty store. It
+// can use t.Cleanup to remove the store after the test.
+//
+// If the store implements decrypt.StopResultSaver, this method is tested too.
+func Run(t *testing.T, newStore func(t *testing.T) decrypt.Store) {
+	for _, tt := range []struct {
+		name string
+		test func(t *testing.T, s decrypt.Store)
+	}{
+		{"unknown poll", testUnknownPoll},
+		{"save key", testSaveKey},
+		{"save key twice", testSaveKeyTwice},
+		{"save key after clear", testSaveKeyAfterClear},
+		{"load or create key", testLoadOrCreateKey},
+		{"load or create existing key", testLoadOrCreateExistingKey},
+		{"load or create key error", testLoadOrCreateKeyError},
+		{"validate signature", testValidateSignature},
+		{"clear poll", testClearPoll},
+		{"set state", testSetState},
+		{"start finished poll", testStartFinishedPoll},
+		{"set expires", testSetExpires},
+		{"list polls", testListPolls},
+		{"concurrent save key", testConcurrentSaveKey},
+		{"concurrent load or create key", testConcurrentLoadOrCreateKey},
+		{"concurrent validate signature", testConcurrentValidateSignature},
+		{"concurrent polls", testConcurrentPolls},
+		{"concurrent start and stop", testConcurrentStartStop},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newStore(t))
+		})
+	}
+
+	t.Run("save stop result", func(t *testing.T) {
+		s := newStore(t)
+		if _, ok := s.(decrypt.StopResultSaver); !ok {
+			t.Skip("store does not implement decrypt.StopResultSaver")
+		}
+		testSaveStopResult(t, s)
+	})
+}
+
+func testUnknownPoll(t *testing.T, s decrypt.Store) {
+	for _, tt := range []struct {
+		name   string
+		call   func() error
+		expect error
+	}{
+		{
+			"LoadKey",
+			func() error { _, err := s.LoadKey("unknown/1"); return err },
+			errorcode.NotExist,
+		},
+		{
+			"ValidateSignature",
+			func() error { return s.ValidateSignature("unknown/1", []byte("sig")) },
+			errorcode.NotExist,
+		},
+		{
+			"LoadPoll",
+			func() error { _, err := s.LoadPoll("unknown/1"); return err },
+			errorcode.NotExist,
+		},
+		{
+			"SetState",
+			func() error { return s.SetState("unknown/1", decrypt.StateStarted) },
+			errorcode.NotExist,
+		},
+		{
+			"SetExpires",
+			func() error { return s.SetExpires("unknown/1", time.Now()) },
+			errorcode.NotExist,
+		},
+		{
+			"ClearPoll",
+			func() error { return s.ClearPoll("unknown/1") },
+			nil,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+
+			if tt.expect == nil {
+				if err != nil {
+					t.Errorf("got error %v, expected none", err)
+				}
+				return
+			}
+
+			if !errors.Is(err, tt.expect) {
+				t.Errorf("got error %v, expected %v", err, tt.expect)
+			}
+		})
+	}
+
+	if _, err := s.LoadPoll("unknown/1"); !errors.Is(err, errorcode.NotExist) {
+		t.Errorf("ClearPoll on an unknown poll created the poll")
+	}
+}
+
+func testSaveKey(t *testing.T, s decrypt.Store) {
+	before := time.Now().Add(-time.Second)
+
+	if err := s.SaveKey("test/1", []byte("my key")); err != nil {
+		t.Fatalf("SaveKey: %v", err)
+	}
+
+	key, err := s.LoadKey("test/1")
+	if err != nil {
+		t.Fatalf("LoadKey: %v", err)
+	}
+
+	if !bytes.Equal(key, []byte("my key")) {
+		t.Errorf("LoadKey returned %q, expected %q", key, "my key")
+	}
+
+	poll := loadPoll(t, s, "test/1")
+
+	if poll.ID != "test/1" {
+		t.Errorf("poll has id %q, expected %q", poll.ID, "test/1")
+	}
+
+	if poll.State != decrypt.StateCreated {
+		t.Errorf("poll has state %s, expected %s", poll.State, decrypt.StateCreated)
+	}
+
+	if poll.Created.Before(before) {
+		t.Errorf("poll has created time %v, expected the time of SaveKey", poll.Created)
+	}
+}
+
+func testSaveKeyTwice(t *testing.T, s decrypt.Store) {
+	if err := s.SaveKey("test/1", []byte("first key")); err != nil {
+		t.Fatalf("SaveKey: %v", err)
+	}
+
+	if err := s.SaveKey("test/1", []byte("second key")); !errors.Is(err, errorcode.Exist) {
+		t.Errorf("second SaveKey returned %v, expected %v", err, errorcode.Exist)
+	}
+
+	key, err := s.LoadKey("test/1")
+	if err != nil {
+		t.Fatalf("LoadKey: %v", err)
+	}
+
+	if !bytes.Equal(key, []byte("first key")) {
+		t.Errorf("LoadKey returned %q, expected the first key", key)
+	}
+}
+
+func testSaveKeyAfterClear(t *testing.T, s decrypt.Store) {
+	if err := s.SaveKey("test/1", []byte("first key")); err != nil {
+		t.Fatalf("SaveKey: %v", err)
+	}
+
+	if err := s.ValidateSignature("test/1", []byte("first sig")); err != nil {
+		t.Fatalf("ValidateSignature: %v", err)
+	}
+
+	if err := s.ClearPoll("test/1"); err != nil {
+		t.Fatalf("ClearPoll: %v", err)
+	}
+
+	if err := s.SaveKey("test/1", []byte("second key")); err != nil {
+		t.Fatalf("SaveKey after ClearPoll: %v", err)
+	}
+
+	key, err := s.LoadKey("test/1")
+	if err != nil {
+		t.Fatalf("LoadKey: %v", err)
+	}
+
+	if !bytes.Equal(key, []byte("second key")) {
+		t.Errorf("LoadKey returned %q, expected the second key", key)
+	}
+
+	poll := loadPoll(t, s, "test/1")
+	if poll.State != decrypt.StateCreated || !poll.Cleared.IsZero() {
+		t.Errorf("got poll %v, expected the meta data of the cleared poll to be replaced", poll)
+	}
+
+	if err := s.ValidateSignature("test/1", []byte("second sig")); err != nil {
+		t.Errorf("ValidateSignature returned %v, expected the old signature to be removed", err)
+	}
+}
+
+func testLoadOrCreateKey(t *testing.T, s decrypt.Store) {
+	var calls int
+	key, created, err := s.LoadOrCreateKey("test/1", func() ([]byte, error) {
+		calls++
+		return []byte("my key"), nil
+	})
+	if err != nil {
+		t.Fatalf("LoadOrCreateKey: %v", err)
+	}
+
+	if calls != 1 {
+		t.Errorf("create was called %d times, expected 1", calls)
+	}
+
+	if !created {
+		t.Errorf("created is false, expected true")
+	}
+
+	if !bytes.Equal(key, []byte("my key")) {
+		t.Errorf("LoadOrCreateKey returned %q, expected %q", key, "my key")
+	}
+
+	saved, err := s.LoadKey("test/1")
+	if err != nil {
+		t.Fatalf("LoadKey: %v", err)
+	}
+
+	if !bytes.Equal(saved, []byte("my key")) {
+		t.Errorf("LoadKey returned %q, expected %q", saved, "my key")
+	}
+
+	if poll := loadPoll(t, s, "test/1"); poll.State != decrypt.StateCreated {
+		t.Errorf("poll has state %s, expected %s", poll.State, decrypt.StateCreated)
+	}
+}
+
+func testLoadOrCreateExistingKey(t *testing.T, s decrypt.Store) {
+	if err := s.SaveKey("test/1", []byte("my key")); err != nil {
+		t.Fatalf("SaveKey: %v", err)
+	}
+
+	key, created, err := s.LoadOrCreateKey("test/1", func() ([]byte, error) {
+		t.Errorf("create was called for an existing key")
+		return []byte("other key"), nil
+	})
+	if err != nil {
+		t.Fatalf("LoadOrCreateKey: %v", err)
+	}
+
+	if created {
+		t.Errorf("created is true, expected false")
+	}
+
+	if !bytes.Equal(key, []byte("my key")) {
+		t.Errorf("LoadOrCreateKey returned %q, expected %q", key, "my key")
+	}
+}
+
+func testLoadOrCreateKeyError(t *testing.T, s decrypt.Store) {
+	errCreate := errors.New("create error")
+
+	_, _, err := s.LoadOrCreateKey("test/1", func() ([]byte, error) {
+		return nil, errCreate
+	})
+	if !errors.Is(err, errCreate) {
+		t.Errorf("LoadOrCreateKey returned %v, expected %v", err, errCreate)
+	}
+
+	if _, err := s.LoadKey("test/1"); !errors.Is(err, errorcode.NotExist) {
+		t.Errorf("LoadKey returned %v, expected %v", err, errorcode.NotExist)
+	}
+}
+
+func testValidateSignature(t *testing.T, s decrypt.Store) {
+	if err := s.SaveKey("test/1", []byte("my key")); err != nil {
+		t.Fatalf("SaveKey: %v", err)
+	}
+
+	for _, tt := range []struct {
+		name   string
+		sig    string
+		expect error
+	}{
+		{"first call", "my sig", nil},
+		{"same signature", "my sig", nil},
+		{"other signature", "other sig", errorcode.Invalid},
+		{"prefix of the signature", "my", errorcode.Invalid},
+		{"same signature again", "my sig", nil},
+	} {
+		err := s.ValidateSignature("test/1", []byte(tt.sig))
+
+		if tt.expect == nil {
+			if err != nil {
+				t.Errorf("%s: got error %v, expected none", tt.name, err)
+			}
+			continue
+		}
+
+		if !errors.Is(err, tt.expect) {
+			t.Errorf("%s: got error %v, expected %v", tt.name, err, tt.expect)
+		}
+	}
+}
+
+func testClearPoll(t *testing.T, s decrypt.Store) {
+	if err := s.SaveKey("test/1", []byte("my key")); err != nil {
+		t.Fatalf("SaveKey: %v", err)
+	}
+
+	if err := s.ValidateSignature("test/1", []byte("my sig")); err != nil {
+		t.Fatalf("ValidateSignature: %v", err)
+	}
+
+	before := time.Now().Add(-time.Second)
+
+	for i := 0; i < 2; i++ {
+		if err := s.ClearPoll("test/1"); err != nil {
+			t.Fatalf("ClearPoll call %d: %v", i+1, err)
+		}
+	}
+
+	if _, err := s.LoadKey("test/1"); !errors.Is(err, errorcode.NotExist) {
+		t.Errorf("LoadKey returned %v, expected %v", err, errorcode.NotExist)
+	}
+
+	if err := s.ValidateSignature("test/1", []byte("my sig")); !errors.Is(err, errorcode.NotExist) {
+		t.Errorf("ValidateSignature returned %v, expected %v", err, errorcode.NotExist)
+	}
+
+	poll := loadPoll(t, s, "test/1")
+
+	if poll.State != decrypt.StateCleared {
+		t.Errorf("poll has state %s, expected %s", poll.State, decrypt.StateCleared)
+	}
+
+	if poll.Cleared.Before(before) {
+		t.Errorf("poll has cleared time %v, expected the time of ClearPoll", poll.Cleared)
+	}
+
+	if poll.Created.IsZero() {
+		t.Errorf("poll has no created time after ClearPoll")
+	}
+}
+
+func testSetState(t *testing.T, s decrypt.Store) {
+	if err := s.SaveKey("test/1", []byte("my key")); err != nil {
+		t.Fatalf("SaveKey: %v", err)
+	}
+
+	before := time.Now().Add(-time.Second)
+
+	for _, state := range []decrypt.PollState{
+		decrypt.StateStarted,
+		decrypt.StateStopped,
+		decrypt.StateExpired,
+	} {
+		if err := s.SetState("test/1", state); err != nil {
+			t.Fatalf("SetState(%s): %v", state, err)
+		}
+
+		poll := loadPoll(t, s, "test/1")
+
+		if poll.State != state {
+			t.Errorf("poll has state %s, expected %s", poll.State, state)
+		}
+
+		if stateTime(poll, state).Before(before) {
+			t.Errorf("poll has time %v for state %s, expected the time of SetState", stateTime(poll, state), state)
+		}
+
+		if poll.Created.IsZero() {
+			t.Errorf("SetState(%s) removed the created time", state)
+		}
+	}
+
+	if _, err := s.LoadKey("test/1"); err != nil {
+		t.Errorf("LoadKey after SetState: %v", err)
+	}
+}
+
+func testStartFinishedPoll(t *testing.T, s decrypt.Store) {
+	for i, state := range []decrypt.PollState{
+		decrypt.StateStopped,
+		decrypt.StateCleared,
+		decrypt.StateExpired,
+	} {
+		id := fmt.Sprintf("test/%d", i)
+		if err := s.SaveKey(id, []byte("my key")); err != nil {
+			t.Fatalf("SaveKey: %v", err)
+		}
+
+		if err := s.SetState(id, state); err != nil {
+			t.Fatalf("SetState(%s): %v", state, err)
+		}
+
+		if err := s.SetState(id, decrypt.StateStarted); !errors.Is(err, errorcode.WrongState) {
+			t.Errorf("starting a %s poll returned %v, expected %v", state, err, errorcode.WrongState)
+		}
+
+		if poll := loadPoll(t, s, id); poll.State != state {
+			t.Errorf("poll has state %s after start, expected %s", poll.State, state)
+		}
+	}
+}
+
+func testSetExpires(t *testing.T, s decrypt.Store) {
+	if err := s.SaveKey("test/1", []byte("my key")); err != nil {
+		t.Fatalf("SaveKey: %v", err)
+	}
+
+	// Backends only have to keep a precision of seconds.
+	expires := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	if err := s.SetExpires("test/1", expires); err != nil {
+		t.Fatalf("SetExpires: %v", err)
+	}
+
+	poll := loadPoll(t, s, "test/1")
+
+	if !poll.Expires.Equal(expires) {
+		t.Errorf("poll expires at %v, expected %v", poll.Expires, expires)
+	}
+
+	if poll.State != decrypt.StateCreated {
+		t.Errorf("SetExpires changed the state to %s", poll.State)
+	}
+}
+
+func testListPolls(t *testing.T, s decrypt.Store) {
+	polls, err := s.ListPolls()
+	if err != nil {
+		t.Fatalf("ListPolls on empty store: %v", err)
+	}
+
+	if len(polls) != 0 {
+		t.Errorf("ListPolls returned %v on an empty store", polls)
+	}
+
+	for i := 1; i <= 3; i++ {
+		if err := s.SaveKey(fmt.Sprintf("test/%d", i), []byte("my key")); err != nil {
+			t.Fatalf("SaveKey: %v", err)
+		}
+	}
+
+	if err := s.SetState("test/2", decrypt.StateStarted); err != nil {
+		t.Fatalf("SetState: %v", err)
+	}
+
+	if err := s.ClearPoll("test/3"); err != nil {
+		t.Fatalf("ClearPoll: %v", err)
+	}
+
+	polls, err = s.ListPolls()
+	if err != nil {
+		t.Fatalf("ListPolls: %v", err)
+	}
+
+	got := make(map[string]decrypt.PollState, len(polls))
+	for _, poll := range polls {
+		if _, ok := got[poll.ID]; ok {
+			t.Errorf("ListPolls returned poll %s more then once", poll.ID)
+		}
+		got[poll.ID] = poll.State
+	}
+
+	expect := map[string]decrypt.PollState{
+		"test/1": decrypt.StateCreated,
+		"test/2": decrypt.StateStarted,
+		"test/3": decrypt.StateCleared,
+	}
+
+	if len(got) != len(expect) {
+		t.Errorf("ListPolls returned %v, expected %v", got, expect)
+	}
+
+	for id, state := range expect {
+		if got[id] != state {
+			t.Errorf("poll %s has state %s, expected %s", id, got[id], state)
+		}
+	}
+}
+
+func testConcurrentSaveKey(t *testing.T, s decrypt.Store) {
+	errs := concurrent(func(i int) error {
+		return s.SaveKey("test/1", []byte(fmt.Sprintf("key %d", i)))
+	})
+
+	var saved int
+	for i, err := range errs {
+		switch {
+		case err == nil:
+			saved++
+		case !errors.Is(err, errorcode.Exist):
+			t.Errorf("SaveKey %d returned %v, expected nil or %v", i, err, errorcode.Exist)
+		}
+	}
+
+	if saved != 1 {
+		t.Errorf("SaveKey succeeded %d times, expected exactly once", saved)
+	}
+}
+
+func testConcurrentLoadOrCreateKey(t *testing.T, s decrypt.Store) {
+	var calls atomic.Int32
+	keys := make([][]byte, concurrency)
+	created := make([]bool, concurrency)
+
+	errs := concurrent(func(i int) error {
+		var err error
+		keys[i], created[i], err = s.LoadOrCreateKey("test/1", func() ([]byte, error) {
+			calls.Add(1)
+			return []byte(fmt.Sprintf("key %d", i)), nil
+		})
+		return err
+	})
+
+	var createdCount int
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("LoadOrCreateKey %d: %v", i, err)
+		}
+
+		if !bytes.Equal(keys[i], keys[0]) {
+			t.Errorf("LoadOrCreateKey %d returned key %q, expected %q", i, keys[i], keys[0])
+		}
+
+		if created[i] {
+			createdCount++
+		}
+	}
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("create was called %d times, expected once", n)
+	}
+
+	if createdCount != 1 {
+		t.Errorf("created was true %d times, expected once", createdCount)
+	}
+}
+
+func testConcurrentValidateSignature(t *testing.T, s decrypt.Store) {
+	if err := s.SaveKey("test/1", []byte("my key")); err != nil {
+		t.Fatalf("SaveKey: %v", err)
+	}
+
+	errs := concurrent(func(i int) error {
+		return s.ValidateSignature("test/1", []byte(fmt.Sprintf("sig %d", i)))
+	})
+
+	var valid int
+	for i, err := range errs {
+		switch {
+		case err == nil:
+			valid++
+		case !errors.Is(err, errorcode.Invalid):
+			t.Errorf("ValidateSignature %d returned %v, expected nil or %v", i, err, errorcode.Invalid)
+		}
+	}
+
+	if valid != 1 {
+		t.Errorf("ValidateSignature accepted %d different signatures, expected exactly one", valid)
+	}
+}
+
+func testConcurrentPolls(t *testing.T, s decrypt.Store) {
+	errs := concurrent(func(i int) error {
+		id := fmt.Sprintf("test/%d", i)
+
+		if err := s.SaveKey(id, []byte(id)); err != nil {
+			return fmt.Errorf("SaveKey: %w", err)
+		}
+
+		if err := s.SetState(id, decrypt.StateStarted); err != nil {
+			return fmt.Errorf("SetState: %w", err)
+		}
+
+		if _, err := s.ListPolls(); err != nil {
+			return fmt.Errorf("ListPolls: %w", err)
+		}
+
+		if err := s.ValidateSignature(id, []byte(id)); err != nil {
+			return fmt.Errorf("ValidateSignature: %w", err)
+		}
+
+		key, err := s.LoadKey(id)
+		if err != nil {
+			return fmt.Errorf("LoadKey: %w", err)
+		}
+
+		if !bytes.Equal(key, []byte(id)) {
+			return fmt.Errorf("LoadKey returned %q, expected %q", key, id)
+		}
+
+		if err := s.ClearPoll(id); err != nil {
+			return fmt.Errorf("ClearPoll: %w", err)
+		}
+
+		return nil
+	})
+
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("poll %d: %v", i, err)
+		}
+	}
+
+	polls, err := s.ListPolls()
+	if err != nil {
+		t.Fatalf("ListPolls: %v", err)
+	}
+
+	if len(polls) != concurrency {
+		t.Errorf("ListPolls returned %d polls, expected %d", len(polls), concurrency)
+	}
+
+	for _, poll := range polls {
+		if poll.State != decrypt.StateCleared {
+			t.Errorf("poll %s has state %s, expected %s", poll.ID, poll.State, decrypt.StateCleared)
+		}
+	}
+}
+
+func testConcurrentStartStop(t *testing.T, s decrypt.Store) {
+	if err := s.SaveKey("test/1", []byte("my key")); err != nil {
+		t.Fatalf("SaveKey: %v", err)
+	}
+
+	errs := concurrent(func(i int) error {
+		if i == 0 {
+			return s.SetState("test/1", decrypt.StateStopped)
+		}
+		return s.SetState("test/1", decrypt.StateStarted)
+	})
+
+	for i, err := range errs {
+		if err != nil && !errors.Is(err, errorcode.WrongState) {
+			t.Errorf("SetState %d returned %v, expected nil or %v", i, err, errorcode.WrongState)
+		}
+	}
+
+	if errs[0] != nil {
+		t.Fatalf("stopping the poll: %v", errs[0])
+	}
+
+	if poll := loadPoll(t, s, "test/1"); poll.State != decrypt.StateStopped {
+		t.Errorf("poll has state %s, expected %s", poll.State, decrypt.StateStopped)
+	}
+}
+
+func testSaveStopResult(t *testing.T, s decrypt.Store) {
+	saver := s.(decrypt.StopResultSaver)
+
+	if err := saver.SaveStopResult("unknown/1", []byte("my sig")); !errors.Is(err, errorcode.NotExist) {
+		t.Errorf("SaveStopResult on an unknown poll returned %v, expected %v", err, errorcode.NotExist)
+	}
+
+	if err := s.SaveKey("test/1", []byte("my key")); err != nil {
+		t.Fatalf("SaveKey: %v", err)
+	}
+
+	if err := s.SetState("test/1", decrypt.StateStarted); err != nil {
+		t.Fatalf("SetState: %v", err)
+	}
+
+	if err := saver.SaveStopResult("test/1", []byte("my sig")); err != nil {
+		t.Fatalf("SaveStopResult: %v", err)
+	}
+
+	stopped := loadPoll(t, s, "test/1")
+	if stopped.State != decrypt.StateStopped {
+		t.Errorf("poll has state %s, expected %s", stopped.State, decrypt.StateStopped)
+	}
+
+	// Make sure, that a second call would get another timestamp.
+	time.Sleep(time.Millisecond)
+
+	if err := saver.SaveStopResult("test/1", []byte("my sig")); err != nil {
+		t.Errorf("SaveStopResult with the same signature: %v", err)
+	}
+
+	if err := saver.SaveStopResult("test/1", []byte("other sig")); !errors.Is(err, errorcode.Invalid) {
+		t.Errorf("SaveStopResult with another signature returned %v, expected %v", err, errorcode.Invalid)
+	}
+
+	poll := loadPoll(t, s, "test/1")
+	if poll.State != decrypt.StateStopped {
+		t.Errorf("poll has state %s, expected %s", poll.State, decrypt.StateStopped)
+	}
+
+	if !poll.Stopped.Equal(stopped.Stopped) {
+		t.Errorf("stopped time changed from %v to %v", stopped.Stopped, poll.Stopped)
+	}
+}
+
+// concurrent calls f from many goroutines at the same time and returns the
+// errors.
+func concurrent(f func(i int) error) []error {
+	errs := make([]error, concurrency)
+	ready := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-ready
+			errs[i] = f(i)
+		}(i)
+	}
+	close(ready)
+	wg.Wait()
+
+	return errs
+}
+
+func loadPoll(t *testing.T, s decrypt.Store, id string) decrypt.Poll {
+	t.Helper()
+
+	poll, err := s.LoadPoll(id)
+	if err != nil {
+		t.Fatalf("LoadPoll: %v", err)
+	}
+	return poll
+}
+
+// stateTime returns the timestamp of the poll for the given state.
+func stateTime(poll decrypt.Poll, state decrypt.PollState) time.Time {
+	switch state {
+	case decrypt.StateCreated:
+		return poll.Created
+	case decrypt.StateStarted:
+		return poll.Started
+	case decrypt.StateStopped:
+		return poll.Stopped
+	case decrypt.StateCleared:
+		return poll.Cleared
+	case decrypt.StateExpired:
+		return poll.Expired
+	}
+	return time.Time{}
+}
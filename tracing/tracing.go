@@ -0,0 +1,60 @@
+// Package tracing initializes OpenTelemetry tracing.
+//
+// The spans are exported with OTLP over gRPC to a collector.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// serviceName is the name of the service in the traces.
+const serviceName = "vote-decrypt"
+
+// Setup sets the global tracer provider, that exports the spans to the
+// collector on the given endpoint.
+//
+// The returned function has to be called on shutdown to flush the remaining
+// spans.
+func Setup(ctx context.Context, endpoint string) (func(context.Context) error, error) {
+	exporter, err := otlptracegrpc.New(
+		ctx,
+		otlptracegrpc.WithEndpoint(endpoint),
+		otlptracegrpc.WithInsecure(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("creating otlp exporter: %w", err)
+	}
+
+	provider := NewProvider(sdktrace.WithBatcher(exporter))
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	return provider.Shutdown, nil
+}
+
+// NewProvider returns a tracer provider with the resource of the service.
+//
+// Can be used in tests with an in memory exporter:
+//
+//	exporter := tracetest.NewInMemoryExporter()
+//	provider := tracing.NewProvider(sdktrace.WithSyncer(exporter))
+func NewProvider(options ...sdktrace.TracerProviderOption) *sdktrace.TracerProvider {
+	res := resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(serviceName),
+	)
+
+	return sdktrace.NewTracerProvider(append([]sdktrace.TracerProviderOption{sdktrace.WithResource(res)}, options...)...)
+}
@@ -0,0 +1,156 @@
+// Package transparency publishes the public main keys and all public poll keys
+// issued by the service.
+//
+// Clients and observers can use it to check, that all voters got the same
+// keys. The poll keys are published as an audit log, that only contains start
+// operations. See the package audit.
+package transparency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/OpenSlides/vote-decrypt/audit"
+)
+
+// MainKey is a public main key of a tenant.
+type MainKey struct {
+	Tenant      string `json:"tenant"`
+	PublicKey   []byte `json:"public_key"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// Fingerprint returns the hex encoded sha256 hash of a public key.
+func Fingerprint(pubKey []byte) string {
+	h := sha256.Sum256(pubKey)
+	return hex.EncodeToString(h[:])
+}
+
+type handler struct {
+	logger *slog.Logger
+	keys   []MainKey
+	sink   audit.Sink
+}
+
+// New returns a http handler, that publishes the keys.
+//
+// mainKeys are the public main keys by tenant. The default main key has the
+// tenant "". sink contains the log of the poll keys. Errors of the requests
+// are written to the logger.
+//
+// The handler provides the paths:
+//
+//	GET /keys: The public main keys and there fingerprints.
+//	GET /log: All entries of the log. The query argument `since` only returns
+//	    entries with a bigger sequence number, `poll_id` only returns entries
+//	    of one poll.
+func New(logger *slog.Logger, mainKeys map[string][]byte, sink audit.Sink) http.Handler {
+	h := handler{logger: logger, sink: sink}
+	for tenant, key := range mainKeys {
+		h.keys = append(h.keys, MainKey{
+			Tenant:      tenant,
+			PublicKey:   key,
+			Fingerprint: Fingerprint(key),
+		})
+	}
+
+	sort.Slice(h.keys, func(i, j int) bool {
+		return h.keys[i].Tenant < h.keys[j].Tenant
+	})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /keys", h.handleKeys)
+	mux.HandleFunc("GET /log", h.handleLog)
+	return mux
+}
+
+func (h handler) handleKeys(w http.ResponseWriter, r *http.Request) {
+	h.writeJSON(w, r, struct {
+		MainKeys []MainKey `json:"main_keys"`
+	}{h.keys})
+}
+
+func (h handler) handleLog(w http.ResponseWriter, r *http.Request) {
+	var since uint64
+	if v := r.URL.Query().Get("since"); v != "" {
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			http.Error(w, "invalid value for since", http.StatusBadRequest)
+			return
+		}
+		since = n
+	}
+	pollID := r.URL.Query().Get("poll_id")
+
+	records, err := h.sink.AuditRecords()
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "reading transparency log", "error", err)
+		http.Error(w, "can not read log", http.StatusInternalServerError)
+		return
+	}
+
+	entries := []json.RawMessage{}
+	for _, record := range records {
+		var entry audit.Entry
+		if err := json.Unmarshal(record, &entry); err != nil {
+			h.logger.ErrorContext(r.Context(), "decoding transparency log", "error", err)
+			http.Error(w, "can not read log", http.StatusInternalServerError)
+			return
+		}
+
+		if entry.Seq <= since || (pollID != "" && entry.PollID != pollID) {
+			continue
+		}
+		entries = append(entries, record)
+	}
+
+	h.writeJSON(w, r, struct {
+		Entries []json.RawMessage `json:"entries"`
+	}{entries})
+}
+
+func (h handler) writeJSON(w http.ResponseWriter, r *http.Request, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.logger.ErrorContext(r.Context(), "writing transparency response", "error", err)
+	}
+}
+
+// Serve runs a http server on the given address with the handler until the
+// context is done.
+func Serve(ctx context.Context, logger *slog.Logger, addr string, handler http.Handler) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+
+	wait := make(chan struct{})
+	go func() {
+		<-ctx.Done()
+		if err := srv.Shutdown(context.Background()); err != nil {
+			logger.Error("shutting down transparency server", "error", err)
+		}
+		close(wait)
+	}()
+
+	logger.Info("running transparency server", "addr", addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("running transparency server: %w", err)
+	}
+
+	<-wait
+
+	return nil
+}
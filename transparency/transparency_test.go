@@ -0,0 +1,136 @@
+package transparency_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OpenSlides/vote-decrypt/audit"
+	"github.com/OpenSlides/vote-decrypt/crypto"
+	"github.com/OpenSlides/vote-decrypt/decrypt"
+	"github.com/OpenSlides/vote-decrypt/transparency"
+)
+
+func get(t *testing.T, handler http.Handler, url string, v any) int {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", url, nil))
+
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+			t.Fatalf("decoding response: %v", err)
+		}
+	}
+	return rec.Code
+}
+
+func TestKeys(t *testing.T) {
+	handler := transparency.New(slog.Default(), map[string][]byte{"": []byte("key"), "tenant1": []byte("key1")}, audit.NewFileSink(filepath.Join(t.TempDir(), "log")))
+
+	var resp struct {
+		MainKeys []transparency.MainKey `json:"main_keys"`
+	}
+	if code := get(t, handler, "/keys", &resp); code != http.StatusOK {
+		t.Fatalf("got status %d", code)
+	}
+
+	if len(resp.MainKeys) != 2 {
+		t.Fatalf("got %d keys, expected 2", len(resp.MainKeys))
+	}
+
+	key := resp.MainKeys[1]
+	if key.Tenant != "tenant1" || string(key.PublicKey) != "key1" || key.Fingerprint != transparency.Fingerprint([]byte("key1")) {
+		t.Errorf("got key %v", key)
+	}
+}
+
+func TestLog(t *testing.T) {
+	signer := crypto.New(make([]byte, 32), rand.Reader, nil)
+	sink := audit.NewFileSink(filepath.Join(t.TempDir(), "log"))
+
+	log, err := audit.New(sink, signer, audit.WithOperations(decrypt.AuditStart))
+	if err != nil {
+		t.Fatalf("audit.New: %v", err)
+	}
+
+	for _, event := range []decrypt.AuditEvent{
+		{Operation: decrypt.AuditStart, PollID: "test/1", PublicKey: []byte("pub1")},
+		{Operation: decrypt.AuditStop, PollID: "test/1"},
+		{Operation: decrypt.AuditStart, PollID: "test/2", PublicKey: []byte("pub2")},
+	} {
+		event.Time = time.Now()
+		if err := log.Record(event); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+
+	handler := transparency.New(slog.Default(), nil, sink)
+
+	for _, tt := range []struct {
+		url    string
+		expect []string
+	}{
+		{"/log", []string{"pub1", "pub2"}},
+		{"/log?since=1", []string{"pub2"}},
+		{"/log?poll_id=test/1", []string{"pub1"}},
+		{"/log?poll_id=unknown", nil},
+	} {
+		t.Run(tt.url, func(t *testing.T) {
+			var resp struct {
+				Entries []audit.Entry `json:"entries"`
+			}
+			if code := get(t, handler, tt.url, &resp); code != http.StatusOK {
+				t.Fatalf("got status %d", code)
+			}
+
+			var got []string
+			for _, entry := range resp.Entries {
+				got = append(got, string(entry.PublicKey))
+			}
+
+			if len(got) != len(tt.expect) {
+				t.Fatalf("got keys %v, expected %v", got, tt.expect)
+			}
+			for i := range got {
+				if got[i] != tt.expect[i] {
+					t.Errorf("got keys %v, expected %v", got, tt.expect)
+				}
+			}
+		})
+	}
+
+	if code := get(t, handler, "/log?since=abc", nil); code != http.StatusBadRequest {
+		t.Errorf("invalid since returned status %d", code)
+	}
+}
+
+// brokenSink is an audit log, that can not be read.
+type brokenSink struct{}
+
+func (brokenSink) AppendAudit([]byte) error { return errors.New("broken") }
+
+func (brokenSink) AuditRecords() ([][]byte, error) { return nil, errors.New("broken") }
+
+func TestLogError(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	handler := transparency.New(logger, nil, brokenSink{})
+
+	if code := get(t, handler, "/log", nil); code != http.StatusInternalServerError {
+		t.Errorf("got status %d, expected %d", code, http.StatusInternalServerError)
+	}
+
+	if !strings.Contains(buf.String(), "reading transparency log") {
+		t.Errorf("error was not written to the logger, got log: %q", buf.String())
+	}
+}